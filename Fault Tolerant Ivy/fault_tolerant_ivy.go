@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -11,6 +13,9 @@ import (
 const TOTAL_NODES int = 3
 const TOTAL_DOCS int = 10
 
+// How long a Lock is held before the CM takes it back, so a dead holder cannot block the waiters forever
+const LOCK_LEASE time.Duration = 2 * time.Second
+
 /*
 Struct to Construct a Central Manager Instance
 */
@@ -21,6 +26,7 @@ type CentralManager struct {
 	cmWaitGroup *sync.WaitGroup
 	pgOwner     map[int]int
 	pgCopies    map[int][]int
+	locks       map[string]*LockState
 	msgReq      chan Message
 	msgRes      chan Message
 	killChan    chan int
@@ -39,8 +45,12 @@ type Node struct {
 	pgAccess      map[int]Permission
 	pgContent     map[int]string
 	writeToPg     string
+	keepContent   bool
+	txMu          sync.Mutex
+	lockRenewals  map[string]chan int
 	msgReq        chan Message
 	msgRes        chan Message
+	lockRes       chan Message
 	cmKillChan    chan int
 }
 
@@ -53,6 +63,7 @@ type Message struct {
 	msgType     MessageType
 	page        int
 	content     string
+	lock        string
 }
 
 /*
@@ -63,6 +74,26 @@ type MetaMsg struct {
 	nodes    map[int]*Node
 	pgOwner  map[int]int
 	pgCopies map[int][]int
+	locks    map[string]*LockState
+}
+
+/*
+Struct to Construct the State of a named Lock at CM, its Holder (0 when free), the FIFO queue of waiters and when the Lease runs out
+*/
+type LockState struct {
+	holder int
+	queue  []int
+	expiry time.Time
+}
+
+/*
+Struct to Construct a Transaction of a Node over a set of Pages, its writes are buffered until Commit
+*/
+type Transaction struct {
+	node    *Node
+	pages   []int
+	writes  map[int]string
+	aborted bool
 }
 
 /*
@@ -77,12 +108,18 @@ const (
 	READACK
 	WRITEACK
 	INVALIDATEACK
+	LOCKREQ
+	TRYLOCKREQ
+	UNLOCKREQ
+	LOCKRENEWREQ
 	//Central Manager to Node Message Types
 	READFWD
 	WRITEFWD
 	INVALIDATE
 	READOWNERNIL
 	WRITEOWNERNIL
+	LOCKGRANT
+	LOCKBUSY
 	//Node to Node
 	READPG
 	WRITEPG
@@ -115,11 +152,17 @@ func (m MessageType) String() string {
 		"READACK",
 		"WRITEACK",
 		"INVALIDATEACK",
+		"LOCKREQ",
+		"TRYLOCKREQ",
+		"UNLOCKREQ",
+		"LOCKRENEWREQ",
 		"READFWD",
 		"WRITEFWD",
 		"INVALIDATE",
 		"READOWNERNIL",
 		"WRITEOWNERNIL",
+		"LOCKGRANT",
+		"LOCKBUSY",
 		"READPG",
 		"WRITEPG",
 	}[m]
@@ -145,8 +188,10 @@ func NewNode(id int, cm CentralManager, backup CentralManager) *Node {
 		pgAccess:      make(map[int]Permission),
 		pgContent:     make(map[int]string),
 		writeToPg:     "",
+		lockRenewals:  make(map[string]chan int),
 		msgReq:        make(chan Message),
 		msgRes:        make(chan Message),
+		lockRes:       make(chan Message),
 		cmKillChan:    make(chan int),
 	}
 
@@ -164,6 +209,7 @@ func NewCM(id int, power OfficeState) *CentralManager {
 		cmWaitGroup: &sync.WaitGroup{},
 		pgOwner:     make(map[int]int),
 		pgCopies:    make(map[int][]int),
+		locks:       make(map[string]*LockState),
 		msgReq:      make(chan Message),
 		msgRes:      make(chan Message),
 		killChan:    make(chan int),
@@ -207,6 +253,9 @@ func (cm *CentralManager) PrintState() {
 	for page, owner := range cm.pgOwner {
 		fmt.Printf("> Page: %d, Owner: %d :: Access Type: %s , Copies: %d\n", page, owner, cm.nodes[owner].pgAccess[page], cm.pgCopies[page])
 	}
+	for name, lock := range cm.locks {
+		fmt.Printf("> Lock: %s, Holder: %d :: Waiting: %d\n", name, lock.holder, lock.queue)
+	}
 }
 
 /*
@@ -218,7 +267,9 @@ func (cm *CentralManager) sendMessage(msg Message, recieverId int) {
 	time.Sleep(time.Millisecond * time.Duration(networkDelay))
 
 	recieverNode := cm.nodes[recieverId]
-	if msg.msgType == READOWNERNIL || msg.msgType == WRITEOWNERNIL {
+	if msg.msgType == LOCKGRANT || msg.msgType == LOCKBUSY {
+		recieverNode.lockRes <- msg
+	} else if msg.msgType == READOWNERNIL || msg.msgType == WRITEOWNERNIL {
 		recieverNode.msgRes <- msg
 	} else {
 		recieverNode.msgReq <- msg
@@ -234,6 +285,7 @@ func (cm *CentralManager) sendMetaMessage(reciever *CentralManager) {
 		nodes:    cm.nodes,
 		pgOwner:  cm.pgOwner,
 		pgCopies: cm.pgCopies,
+		locks:    cm.copyLocks(),
 	}
 	fmt.Printf("> [CM %d] Sending MetaMsg to Backup CM %d\n", cm.id, reciever.id)
 
@@ -313,6 +365,21 @@ func (cm *CentralManager) handleWriteReq(msg Message) {
 	cm.cmWaitGroup.Done()
 }
 
+/*
+Function to copy the Lock table for the Backup CM, so it gets the Holders, waiters and renewed Leases as they are now
+*/
+func (cm *CentralManager) copyLocks() map[string]*LockState {
+	locks := make(map[string]*LockState)
+	for name, lock := range cm.locks {
+		locks[name] = &LockState{
+			holder: lock.holder,
+			queue:  append([]int{}, lock.queue...),
+			expiry: lock.expiry,
+		}
+	}
+	return locks
+}
+
 /*
 Function to handle Incoming Met Data Msgs at CM
 */
@@ -320,25 +387,150 @@ func (cm *CentralManager) handleMetaMsg(msg MetaMsg) {
 	cm.nodes = msg.nodes
 	cm.pgCopies = msg.pgCopies
 	cm.pgOwner = msg.pgOwner
+	cm.locks = msg.locks
 
 	fmt.Printf("> [CM %d] Synced MetaMessage from Incumbent CM %d\n", cm.id, msg.senderId)
 }
 
+/*
+Function to get the Lock State of a named Lock at CM, a Lock is created free the first time it is used
+*/
+func (cm *CentralManager) lockState(name string) *LockState {
+	lock, exists := cm.locks[name]
+	if !exists {
+		lock = &LockState{}
+		cm.locks[name] = lock
+	}
+	return lock
+}
+
+/*
+Function to give a Lock to a Node and start its Lease
+*/
+func (cm *CentralManager) grantLock(name string, nodeId int) {
+	lock := cm.lockState(name)
+	lock.holder = nodeId
+	lock.expiry = time.Now().Add(LOCK_LEASE)
+	grantMsg := createMessage(LOCKGRANT, 0, nodeId, 0, "")
+	grantMsg.lock = name
+	go cm.sendMessage(*grantMsg, nodeId)
+	cm.cmWaitGroup.Done()
+}
+
+/*
+Function to pass a released Lock to the first Node waiting for it, or mark it free
+*/
+func (cm *CentralManager) grantNext(name string) {
+	lock := cm.lockState(name)
+	lock.holder = 0
+	if len(lock.queue) == 0 {
+		return
+	}
+	next := lock.queue[0]
+	lock.queue = lock.queue[1:]
+	cm.grantLock(name, next)
+}
+
+/*
+Function to handle Incoming Lock Request Msgs at CM, a held Lock queues the Node in FIFO order until it is released
+*/
+func (cm *CentralManager) handleLockReq(msg Message) {
+	lock := cm.lockState(msg.lock)
+	if lock.holder == 0 {
+		cm.grantLock(msg.lock, msg.requesterId)
+		return
+	}
+	fmt.Printf("> [CM %d] Lock %s is held by Node %d, Node %d is waiting\n", cm.id, msg.lock, lock.holder, msg.requesterId)
+	lock.queue = append(lock.queue, msg.requesterId)
+}
+
+/*
+Function to handle Incoming Try Lock Request Msgs at CM, a held Lock is answered with LOCKBUSY instead of queueing
+*/
+func (cm *CentralManager) handleTryLockReq(msg Message) {
+	lock := cm.lockState(msg.lock)
+	if lock.holder == 0 {
+		cm.grantLock(msg.lock, msg.requesterId)
+		return
+	}
+	replyMsg := createMessage(LOCKBUSY, 0, msg.requesterId, 0, "")
+	replyMsg.lock = msg.lock
+	go cm.sendMessage(*replyMsg, msg.requesterId)
+	cm.cmWaitGroup.Done()
+}
+
+/*
+Function to handle Incoming Unlock Request Msgs at CM, an Unlock from a Node whose Lease already ran out is ignored
+*/
+func (cm *CentralManager) handleUnlockReq(msg Message) {
+	lock := cm.lockState(msg.lock)
+	if lock.holder != msg.requesterId {
+		fmt.Printf("> [CM %d] Node %d no longer holds Lock %s, its Lease ran out\n", cm.id, msg.requesterId, msg.lock)
+	} else {
+		cm.grantNext(msg.lock)
+	}
+	cm.cmWaitGroup.Done()
+}
+
+/*
+Function to handle Incoming Lock Renew Request Msgs at CM, the Holder's Lease starts again, a renewal from a Node that
+no longer holds the Lock is ignored
+*/
+func (cm *CentralManager) handleRenewReq(msg Message) {
+	lock, exists := cm.locks[msg.lock]
+	if !exists || lock.holder != msg.requesterId {
+		fmt.Printf("> [CM %d] Node %d no longer holds Lock %s, not renewing its Lease\n", cm.id, msg.requesterId, msg.lock)
+		return
+	}
+	lock.expiry = time.Now().Add(LOCK_LEASE)
+}
+
+/*
+Function to take back every Lock whose Lease ran out and pass it on to the next waiter, only the Incumbent CM does this
+*/
+func (cm *CentralManager) expireLeases() {
+	if cm.power != INCUMBENT {
+		return
+	}
+	now := time.Now()
+	for name, lock := range cm.locks {
+		if lock.holder != 0 && now.After(lock.expiry) {
+			fmt.Printf("> [CM %d] Lease of Node %d on Lock %s ran out\n", cm.id, lock.holder, name)
+			cm.grantNext(name)
+		}
+	}
+}
+
 /*
 Function to handle Incoming Msgs at CM
 */
 func (cm *CentralManager) handleIncomingMessages() {
+	leaseTicker := time.NewTicker(LOCK_LEASE / 4)
+	defer leaseTicker.Stop()
 	for {
 		select {
 		case reqMsg := <-cm.msgReq:
-			cm.power = INCUMBENT
 			fmt.Printf("> [CM %d] Recieved Message of type %s from Node %d\n", cm.id, reqMsg.msgType, reqMsg.senderId)
+			if reqMsg.msgType == LOCKRENEWREQ {
+				// A renewal sent before a failover can still land here, it does not make this CM the Incumbent
+				cm.handleRenewReq(reqMsg)
+				continue
+			}
+			cm.power = INCUMBENT
 			switch reqMsg.msgType {
 			case READREQ:
 				cm.handleReadReq(reqMsg)
 			case WRITEREQ:
 				cm.handleWriteReq(reqMsg)
+			case LOCKREQ:
+				cm.handleLockReq(reqMsg)
+			case TRYLOCKREQ:
+				cm.handleTryLockReq(reqMsg)
+			case UNLOCKREQ:
+				cm.handleUnlockReq(reqMsg)
 			}
+		case <-leaseTicker.C:
+			cm.expireLeases()
 		case metaMsg := <-cm.cmChan:
 			//write code to handle
 			cm.power = OVERTHROWN
@@ -364,16 +556,26 @@ func (node *Node) sendMessage(msg Message, recieverId int) {
 	networkDelay := rand.Intn(50)
 	time.Sleep(time.Millisecond * time.Duration(networkDelay))
 	if recieverId == 0 {
-		if msg.msgType == READREQ || msg.msgType == WRITEREQ {
+		if msg.msgType == READREQ || msg.msgType == WRITEREQ || msg.msgType == LOCKREQ || msg.msgType == TRYLOCKREQ || msg.msgType == UNLOCKREQ || msg.msgType == LOCKRENEWREQ {
 			node.cm.msgReq <- msg
 		} else if msg.msgType == INVALIDATEACK || msg.msgType == READACK || msg.msgType == WRITEACK {
 			node.cm.msgRes <- msg
 		}
 	} else {
-		node.nodes[recieverId].msgRes <- msg
+		node.peer(recieverId).msgRes <- msg
 	}
 }
 
+/*
+Function to get another Node by id, a Node that is both the Owner and the Requester of a Page sends to itself
+*/
+func (node *Node) peer(id int) *Node {
+	if id == node.id {
+		return node
+	}
+	return node.nodes[id]
+}
+
 /*
 Function to handle Read Forward Msgs at Node
 */
@@ -463,6 +665,9 @@ func (node *Node) handleWritePg(msg Message) {
 	content := msg.content
 
 	fmt.Printf("> [Node %d] Recieved Old Page %d Content from Owner for Writing\n Content: %s\n", node.id, page, content)
+	if node.keepContent {
+		node.writeToPg = content
+	}
 	node.pgAccess[page] = READWRITE
 	node.pgContent[page] = node.writeToPg
 	fmt.Printf("> [Node %d] Writing to Page %d\n Content: %s\n", node.id, page, node.writeToPg)
@@ -479,6 +684,8 @@ func (node *Node) handleIncomingMessage() {
 		select {
 		case msg := <-node.msgReq:
 			fmt.Printf("> [Node %d] Recieved Message of type %s from CM\n", node.id, msg.msgType)
+			// A Transaction committing on this Node finishes before its Pages can be taken away
+			node.txMu.Lock()
 			switch msg.msgType {
 			case READFWD:
 				node.handleReadFwd(msg)
@@ -487,6 +694,7 @@ func (node *Node) handleIncomingMessage() {
 			case INVALIDATE:
 				node.handleInvalidate(msg)
 			}
+			node.txMu.Unlock()
 
 		case <-node.cmKillChan:
 			//handle killing of cm, swap primary and backup with each other
@@ -559,6 +767,239 @@ func (node *Node) executeWrite(page int, content string) {
 	}
 }
 
+/*
+Function to acquire a named Lock at Node, blocking until the CM grants it
+*/
+func (node *Node) Lock(name string) {
+	node.nodeWaitGroup.Add(1)
+	lockReqMsg := createMessage(LOCKREQ, node.id, node.id, 0, "")
+	lockReqMsg.lock = name
+	go node.sendMessage(*lockReqMsg, 0)
+
+	node.awaitLockReply(name)
+	node.renewLease(name)
+}
+
+/*
+Function to try to acquire a named Lock at Node without waiting, returns whether it was granted
+*/
+func (node *Node) TryLock(name string) bool {
+	node.nodeWaitGroup.Add(1)
+	lockReqMsg := createMessage(TRYLOCKREQ, node.id, node.id, 0, "")
+	lockReqMsg.lock = name
+	go node.sendMessage(*lockReqMsg, 0)
+
+	msg := node.awaitLockReply(name)
+	if msg.msgType != LOCKGRANT {
+		return false
+	}
+	node.renewLease(name)
+	return true
+}
+
+/*
+Function to wait for the CM's answer about a named Lock, Lock replies have their own channel so they never get mixed up
+with the replies to Reads and Writes
+*/
+func (node *Node) awaitLockReply(name string) Message {
+	for {
+		msg := <-node.lockRes
+		if msg.lock == name {
+			fmt.Printf("> [Node %d] Recieved Message of type %s for Lock %s\n", node.id, msg.msgType, name)
+			return msg
+		}
+		fmt.Printf("> [Node %d] Dropped Message of type %s for Lock %s it is not waiting for\n", node.id, msg.msgType, msg.lock)
+	}
+}
+
+/*
+Function to keep renewing the Lease on a held Lock every quarter Lease, at whichever CM the Node knows as Incumbent,
+so the CM only takes back a Lock whose Holder has died
+*/
+func (node *Node) renewLease(name string) {
+	node.stopRenewing(name)
+	stop := make(chan int)
+	node.lockRenewals[name] = stop
+	go func() {
+		ticker := time.NewTicker(LOCK_LEASE / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				renewReqMsg := createMessage(LOCKRENEWREQ, node.id, node.id, 0, "")
+				renewReqMsg.lock = name
+				go node.sendMessage(*renewReqMsg, 0)
+			}
+		}
+	}()
+}
+
+/*
+Function to stop renewing the Lease on a Lock the Node gives up
+*/
+func (node *Node) stopRenewing(name string) {
+	if stop, exists := node.lockRenewals[name]; exists {
+		close(stop)
+		delete(node.lockRenewals, name)
+	}
+}
+
+/*
+Function to release a named Lock at Node
+*/
+func (node *Node) Unlock(name string) {
+	node.nodeWaitGroup.Add(1)
+	node.stopRenewing(name)
+	unlockReqMsg := createMessage(UNLOCKREQ, node.id, node.id, 0, "")
+	unlockReqMsg.lock = name
+	go node.sendMessage(*unlockReqMsg, 0)
+}
+
+/*
+Function to take write ownership of a Page at Node without changing its Content
+*/
+func (node *Node) acquireOwnership(page int) {
+	node.nodeWaitGroup.Add(1)
+	node.writeToPg = node.pgContent[page]
+	node.keepContent = true
+	writeReqMsg := createMessage(WRITEREQ, node.id, node.id, page, "")
+	go node.sendMessage(*writeReqMsg, 0)
+
+	msg := <-node.msgRes
+	switch msg.msgType {
+	case WRITEOWNERNIL:
+		node.handleWriteOwnerNil(msg)
+	case WRITEPG:
+		node.handleWritePg(msg)
+	}
+	node.keepContent = false
+}
+
+/*
+Function to get the name of the Lock a Transaction takes on a Page
+*/
+func txLockName(page int) string {
+	return fmt.Sprintf("page-%d", page)
+}
+
+/*
+Function to begin a Transaction over a set of Pages at Node, the Lock of every Page is taken in ascending Page order
+so two Transactions can never wait on each other
+*/
+func (node *Node) Begin(pages []int) *Transaction {
+	sorted := []int{}
+	for _, page := range pages {
+		if !inArray(page, sorted) {
+			sorted = append(sorted, page)
+		}
+	}
+	sort.Ints(sorted)
+	fmt.Printf("> [Node %d] Beginning Transaction over Pages %d\n", node.id, sorted)
+	for _, page := range sorted {
+		node.Lock(txLockName(page))
+	}
+
+	tx := Transaction{
+		node:   node,
+		pages:  sorted,
+		writes: make(map[int]string),
+	}
+	return &tx
+}
+
+/*
+Function to read a Page in a Transaction, a Page written earlier in the Transaction reads as written
+*/
+func (tx *Transaction) Read(page int) string {
+	if content, written := tx.writes[page]; written {
+		return content
+	}
+	if !inArray(page, tx.pages) {
+		fmt.Printf("> [Node %d] Page %d is not part of the Transaction, it will abort\n", tx.node.id, page)
+		tx.aborted = true
+	}
+	tx.node.executeRead(page)
+	return tx.node.pgContent[page]
+}
+
+/*
+Function to write a Page in a Transaction, nothing is visible to other Nodes before Commit
+*/
+func (tx *Transaction) Write(page int, content string) {
+	if !inArray(page, tx.pages) {
+		fmt.Printf("> [Node %d] Page %d is not part of the Transaction, it will abort\n", tx.node.id, page)
+		tx.aborted = true
+		return
+	}
+	tx.writes[page] = content
+}
+
+/*
+Function to commit a Transaction, write ownership of every written Page is taken from whichever CM is Incumbent and
+all writes are applied at once on this Node while no other Node holds a Copy, returns false if the Transaction aborted instead
+*/
+func (tx *Transaction) Commit() bool {
+	if tx.aborted {
+		tx.Abort()
+		return false
+	}
+	node := tx.node
+	written := []int{}
+	for _, page := range tx.pages {
+		if _, exists := tx.writes[page]; exists {
+			written = append(written, page)
+		}
+	}
+
+	for {
+		for _, page := range written {
+			if accessType, exists := node.pgAccess[page]; !exists || accessType != READWRITE {
+				node.acquireOwnership(page)
+			}
+		}
+
+		// A plain read may have taken a Copy in the meantime, then ownership is taken again
+		node.txMu.Lock()
+		owned := true
+		for _, page := range written {
+			if accessType, exists := node.pgAccess[page]; !exists || accessType != READWRITE {
+				owned = false
+			}
+		}
+		if owned {
+			for _, page := range written {
+				node.pgContent[page] = tx.writes[page]
+			}
+			fmt.Printf("> [Node %d] Committed Transaction writing Pages %d\n", node.id, written)
+			node.txMu.Unlock()
+			break
+		}
+		node.txMu.Unlock()
+	}
+	tx.release()
+	return true
+}
+
+/*
+Function to abort a Transaction, none of its writes are applied
+*/
+func (tx *Transaction) Abort() {
+	fmt.Printf("> [Node %d] Aborted Transaction over Pages %d\n", tx.node.id, tx.pages)
+	tx.writes = make(map[int]string)
+	tx.release()
+}
+
+/*
+Function to release the Locks of a finished Transaction
+*/
+func (tx *Transaction) release() {
+	for i := len(tx.pages) - 1; i >= 0; i-- {
+		tx.node.Unlock(txLockName(tx.pages[i]))
+	}
+}
+
 /*
 Function to communicate Meta Data Message from Incumbent to Backup CM periodically
 */
@@ -619,7 +1060,7 @@ func main() {
 	fmt.Printf("The network will have %d Nodes.\n", TOTAL_NODES)
 	fmt.Printf("The network will have 2 CMs, CM 0 is Primary and CM 1 is a Backup.\n")
 
-	fmt.Printf("\n\nThe program will start soon....\nInstructions:\n\nType 1 and Hit ENTER to Simulate BASELINE FAULT FREE BENCHMARK\nor 2 and Hit ENTER to Simulate PRIMARY CM FAULT (DEAD) BENCHMARK\nor 3 and Hit ENTER to PRIMARY CM FAULT (DEAD AND RESTART) BENCHMARK\nor 4 and Hit ENTER to Simulate a MULTIPLE PRIMARY CM FAULT (DEAD AND RESTART) BENCHMARK\nor 5 and Hit ENTER to Simulate a MULTIPLE PRIMARY CM AND BACKUP CM FAULT (DEAD AND RESTART) BENCHMARK\nor Type EXIT and Hit ENTER to exit...\n\n")
+	fmt.Printf("\n\nThe program will start soon....\nInstructions:\n\nType 1 and Hit ENTER to Simulate BASELINE FAULT FREE BENCHMARK\nor 2 and Hit ENTER to Simulate PRIMARY CM FAULT (DEAD) BENCHMARK\nor 3 and Hit ENTER to PRIMARY CM FAULT (DEAD AND RESTART) BENCHMARK\nor 4 and Hit ENTER to Simulate a MULTIPLE PRIMARY CM FAULT (DEAD AND RESTART) BENCHMARK\nor 5 and Hit ENTER to Simulate a MULTIPLE PRIMARY CM AND BACKUP CM FAULT (DEAD AND RESTART) BENCHMARK\nor 6 and Hit ENTER to Simulate a PRIMARY CM FAULT (DEAD) WHILE A LOCK IS HELD BENCHMARK\nor 7 and Hit ENTER to Simulate a PRIMARY CM FAULT (DEAD) DURING A TRANSACTION BENCHMARK\nor Type EXIT and Hit ENTER to exit...\n\n")
 
 	cm := NewCM(0, INCUMBENT)
 	cm.cmWaitGroup = &wg
@@ -954,6 +1395,111 @@ func main() {
 
 		}
 
+		if random == "6" {
+			fmt.Printf("**************************************************\n PRIMARY CM FAULT (DEAD) WHILE A LOCK IS HELD BENCHMARK  \n**************************************************\n")
+			go func() {
+				start := time.Now()
+				nodeMap[1].Lock("x")
+				go func() {
+					nodeMap[2].Lock("x")
+					nodeMap[2].executeWrite(1, "This is written by node id 2 holding Lock x")
+					nodeMap[2].Unlock("x")
+				}()
+				// Let the queued Lock Request reach the Primary CM and be synced to the Backup CM
+				time.Sleep(300 * time.Millisecond)
+				fmt.Printf("**************************************************\n KILLING PRIMARY CM  \n**************************************************\n")
+				cm.killChan <- 1
+				for _, node := range nodeMap {
+					node.cmKillChan <- 1
+				}
+				time.Sleep(100 * time.Millisecond)
+				// Make CM Realise its no longer Incumbent
+				go cm.periodicFunction(backupCM)
+				// Make Dead CM relive by listening to msgs again
+				go cm.handleIncomingMessages()
+
+				// The Backup CM becomes Incumbent, Node 1 keeps Lock x past its Lease by renewing it there
+				nodeMap[3].executeRead(1)
+				time.Sleep(LOCK_LEASE + LOCK_LEASE/2)
+
+				// The Backup CM passes the Lock on to Node 2 that queued at the dead Primary CM
+				nodeMap[1].Unlock("x")
+				time.Sleep(500 * time.Millisecond)
+
+				// Node 3 takes the Lock and dies before releasing it, so its renewals stop and Node 1 waits for its Lease to run out
+				nodeMap[3].Lock("x")
+				nodeMap[3].stopRenewing("x")
+				if !nodeMap[1].TryLock("x") {
+					nodeMap[1].Lock("x")
+				}
+				nodeMap[1].executeRead(1)
+				nodeMap[1].Unlock("x")
+
+				wg.Wait()
+				end := time.Now()
+				time.Sleep(time.Duration(1) * time.Second)
+				fmt.Printf("**************************************************\n CONCLUSION  \n**************************************************\n")
+				cm.PrintState()
+				backupCM.PrintState()
+				fmt.Printf("Time taken = %.2f seconds \n", end.Sub(start).Seconds())
+				os.Exit(0)
+			}()
+		}
+
+		if random == "7" {
+			fmt.Printf("**************************************************\n PRIMARY CM FAULT (DEAD) DURING A TRANSACTION BENCHMARK  \n**************************************************\n")
+			go func() {
+				start := time.Now()
+				setup := nodeMap[1].Begin([]int{1, 2})
+				setup.Write(1, "50")
+				setup.Write(2, "50")
+				setup.Commit()
+				// Node 3 takes Copies, so the Commit below needs the CM again
+				nodeMap[3].executeRead(1)
+				nodeMap[3].executeRead(2)
+
+				tx := nodeMap[1].Begin([]int{1, 2})
+				from, _ := strconv.Atoi(tx.Read(1))
+				to, _ := strconv.Atoi(tx.Read(2))
+				tx.Write(1, strconv.Itoa(from-30))
+				tx.Write(2, strconv.Itoa(to+30))
+
+				seen := make(chan int)
+				go func() {
+					check := nodeMap[2].Begin([]int{1, 2})
+					from, _ := strconv.Atoi(check.Read(1))
+					to, _ := strconv.Atoi(check.Read(2))
+					check.Commit()
+					seen <- from + to
+				}()
+				// Let the queued Lock Request reach the Primary CM and be synced to the Backup CM
+				time.Sleep(300 * time.Millisecond)
+				fmt.Printf("**************************************************\n KILLING PRIMARY CM  \n**************************************************\n")
+				cm.killChan <- 1
+				for _, node := range nodeMap {
+					node.cmKillChan <- 1
+				}
+				time.Sleep(100 * time.Millisecond)
+				// Make CM Realise its no longer Incumbent
+				go cm.periodicFunction(backupCM)
+				// Make Dead CM relive by listening to msgs again
+				go cm.handleIncomingMessages()
+
+				tx.Commit()
+				total := <-seen
+
+				wg.Wait()
+				end := time.Now()
+				time.Sleep(time.Duration(1) * time.Second)
+				fmt.Printf("**************************************************\n CONCLUSION  \n**************************************************\n")
+				cm.PrintState()
+				backupCM.PrintState()
+				fmt.Printf("Page 1: %s , Page 2: %s , Total seen by Node 2 after the failover: %d , Expected: 100\n", nodeMap[1].pgContent[1], nodeMap[1].pgContent[2], total)
+				fmt.Printf("Time taken = %.2f seconds \n", end.Sub(start).Seconds())
+				os.Exit(0)
+			}()
+		}
+
 		if random == "EXIT" {
 			os.Exit(0)
 		}
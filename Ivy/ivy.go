@@ -2,24 +2,294 @@ package main
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const TOTAL_NODES int = 7
 const TOTAL_DOCS int = 10
 
+// Number of nodes holding each page after a write (owner + backups), 1 disables replication
+const REPLICATION_FACTOR int = 1
+
+// Where the directory entry of each page lives, see ManagerMode
+const MANAGER_MODE ManagerMode = CENTRALIZED
+
+// Whether a write invalidates the copies of a Page or pushes its new Content to them, see CoherencePolicy
+const COHERENCE_POLICY CoherencePolicy = WRITE_INVALIDATE
+
+// Whether writes are made visible at once or only at Release, see ConsistencyModel
+const CONSISTENCY_MODEL ConsistencyModel = SEQUENTIAL
+
+// Number of CM Shards and virtual points per Shard on the consistent hashing ring in SHARDED mode
+const SHARD_COUNT int = 3
+const SHARD_VIRTUAL_NODES int = 16
+
+// Run the baseline benchmark for the centralized and broadcast managers at 3, 7 and 20 Nodes and compare Message counts
+const COMPARE_MESSAGE_COUNTS bool = false
+
+// Run a read-mostly polling workload under write-invalidate and write-update and compare them
+const COMPARE_COHERENCE_POLICIES bool = false
+
+// Run a batch workload of repeated writes between Acquire and Release under both consistency models and compare them
+const COMPARE_CONSISTENCY_MODELS bool = false
+
+// Run a false sharing workload, two Nodes writing different bytes of one Page, under every consistency model and compare them
+const COMPARE_FALSE_SHARING bool = false
+
+// How long a Lock is held before the CM takes it back, so a dead holder cannot block the waiters forever
+const LOCK_LEASE time.Duration = 2 * time.Second
+
+// Run a shared counter workload where every Node increments a Page inside Lock and Unlock
+const LOCK_BENCHMARK bool = false
+
+// Run the shared counter workload with Pages bound to the Lock under both sequential and entry consistency and compare them
+const COMPARE_ENTRY_CONSISTENCY bool = false
+
+// Run a phased workload separated by Barriers and a producer/consumer workload on a Cond
+const BARRIER_BENCHMARK bool = false
+
+// Run concurrent increments of a counter Page with plain reads and writes and with atomic operations and compare them
+const ATOMIC_BENCHMARK bool = false
+
+// Let the CM ship a registered Operation to the Owner of a contended Page instead of migrating the Page to the caller
+const FUNCTION_SHIPPING bool = false
+
+// A Page is contended when SHIP_CONTENDERS different Nodes are among its last SHIP_WINDOW writers
+const SHIP_WINDOW int = 6
+const SHIP_CONTENDERS int = 3
+
+// Run concurrent Operations on a counter Page with and without function shipping and compare them
+const COMPARE_FUNCTION_SHIPPING bool = false
+
+// Run concurrent transfers between two account Pages with plain reads and writes and with Transactions and compare them
+const TRANSACTION_BENCHMARK bool = false
+
+// How long a READONLY Copy stays valid, a writer waits at most this long for an unresponsive reader (0 turns read leases off)
+const READ_LEASE time.Duration = 0
+
+// Run a write to a Page whose reader stopped responding with and without read leases
+const LEASE_BENCHMARK bool = false
+
+// How long a new Owner keeps a Page before the CM lets a write take it away again (0 turns the window off)
+const OWNERSHIP_WINDOW time.Duration = 0
+
+// Run two Nodes alternating writes to one Page with and without the ownership window and compare them
+const THRASH_BENCHMARK bool = false
+
+// Let a Node holding a READONLY Copy upgrade it to READWRITE in place instead of fetching the Page from its Owner
+const READ_UPGRADE bool = false
+
+// Run reads followed by writes of the same Page with and without upgrades and compare them
+const UPGRADE_BENCHMARK bool = false
+
+// Let a write that replaces the whole Page take ownership from the Owner without the Old Content being sent
+const BLIND_WRITES bool = false
+
+// Run whole Page overwrites with and without blind writes and compare the Content Bytes sent
+const BLIND_WRITE_BENCHMARK bool = false
+
+// Let the CM spot Pages that one Node after another reads and then writes, and answer a read of such a Page with write ownership
+const MIGRATORY_DETECTION bool = false
+
+// How many times in a row a Node has to write a Page it just read before the Page counts as migratory
+const MIGRATORY_THRESHOLD int = 2
+
+// Run Nodes incrementing a counter Page in turn with and without migratory detection and compare them
+const MIGRATORY_BENCHMARK bool = false
+
+// How many Pages after a faulting Page a read also fetches READONLY from the same Owner (0 turns prefetching off)
+const PREFETCH_PAGES int = 0
+
+// Run sequential scans over the Pages with and without prefetching and compare them
+const PREFETCH_BENCHMARK bool = false
+
+// Run a polling Workload on the Centralized and the Improved Centralized Manager and compare the work done by the CM
+const COMPARE_IMPROVED_MANAGER bool = false
+
+// How long a Node waits for the Owner to answer a broadcast Request before it broadcasts the Request again
+const BROADCAST_RETRY time.Duration = 500 * time.Millisecond
+
+// Send every Message through one Outbox per sender, FIFO towards each receiver, instead of a goroutine per Message
+const ORDERED_LINKS bool = true
+
+// How many Messages an Outbox holds before the sender has to wait
+const OUTBOX_SIZE int = 32
+
+// Run a concurrent Workload with and without Outboxes and compare the number of goroutines
+const OUTBOX_BENCHMARK bool = false
+
+// Run a randomized concurrent Workload of Local Operations on every Node and check that it finishes
+const DISPATCHER_BENCHMARK bool = false
+
+// How long the protocol may make no progress while work is outstanding before the watchdog dumps its state (0 turns it off)
+const STALL_TIMEOUT time.Duration = 0
+
+// Stall a write on a reader that stopped responding and let the watchdog explain it
+const STALL_BENCHMARK bool = false
+
+// Number of Messages sent by every CM and Node since the last reset
+var messagesSent int64
+
+// Total bytes of Page Content carried by every Message sent so far
+var contentBytesSent int64
+
+// Number of Messages sent by or to a CM since the last reset
+var managerMessages int64
+
+// Number of Local Operations submitted so far, the next one gets the next number as its ID
+var opIds int64
+
+// Sequence number of the last Message sent
+var messageSeq int64
+
+// Messages sent but not yet taken by their receiver, by sequence number
+var inFlight = make(map[int64]InFlight)
+var inFlightMu sync.Mutex
+
+// Mailboxes of the receiving channels, created with the first Message for the channel
+var mailboxes = make(map[chan Message]*Mailbox)
+var mailboxesMu sync.Mutex
+
+// When the protocol last made progress in UnixNano, a Message sent or taken by its receiver or a protocol step finished
+var lastProgress int64
+
+/*
+Operation that can be applied to a Page wherever it lives, it returns the new Content and a result for the caller
+*/
+type Operation func(content string, arg string) (string, string)
+
+// Operations registered by name, a name is all that travels in a Message
+var operations = map[string]Operation{
+	"add": func(content string, arg string) (string, string) {
+		count, _ := strconv.Atoi(content)
+		delta, _ := strconv.Atoi(arg)
+		return strconv.Itoa(count + delta), content
+	},
+	"append": func(content string, arg string) (string, string) {
+		return content + arg, content
+	},
+}
+
+/*
+Struct to Construct an Envelope, a Message waiting in an Outbox with the channel it goes to, the time it gets there
+and the slots of the Outbox it takes up until the receiver takes the Message
+*/
+type Envelope struct {
+	msg       Message
+	inbox     chan Message
+	deliverAt time.Time
+	slots     chan int
+}
+
+/*
+Struct to Construct the Outbox of a CM or Node, its Envelopes in the order they are due, a Message is never due before
+an earlier one to the same receiver so every pair of endpoints is FIFO, drained by one goroutine that only runs while
+Messages are pending
+*/
+type Outbox struct {
+	mu     sync.Mutex
+	queue  []Envelope
+	due    map[string]time.Time
+	slots  chan int
+	wake   chan int
+	active bool
+	closed bool
+}
+
+/*
+Struct to Construct the Mailbox of a receiving channel, Messages handed over by Outboxes wait here until the receiver
+takes them, so an Outbox never waits on a busy receiver, each of them still holds its slot in the Outbox it came from
+*/
+type Mailbox struct {
+	mu     sync.Mutex
+	queue  []Envelope
+	ready  chan int
+	done   chan int
+	closed bool
+}
+
+/*
+Struct to Construct an In Flight entry, a Message that was sent but not yet taken by its receiver
+*/
+type InFlight struct {
+	msg    Message
+	from   string
+	to     string
+	sentAt time.Time
+}
+
+/*
+Struct to Construct a Local Operation submitted to a Node's dispatcher with the handle its submitter waits on
+*/
+type LocalOp struct {
+	run    func() string
+	handle *OpHandle
+}
+
+/*
+Struct to Construct the completion handle of a Local Operation, done is closed once the Operation returned
+and every protocol step it started has finished, result holds what the Operation returned
+*/
+type OpHandle struct {
+	id       int64
+	mu       sync.Mutex
+	pending  int
+	ran      bool
+	resolved bool
+	done     chan int
+	result   string
+}
+
 /*
 Struct to Construct a Central Manager Instance
 */
 type CentralManager struct {
-	nodes       map[int]*Node
-	cmWaitGroup *sync.WaitGroup
-	pgOwner     map[int]int
-	pgCopies    map[int][]int
-	msgReq      chan Message
-	msgRes      chan Message
+	id                int
+	nodes             map[int]*Node
+	pgOwner           map[int]int
+	pgCopies          map[int][]int
+	pgBackups         map[int][]int
+	pgPolicy          map[int]CoherencePolicy
+	policyMu          sync.RWMutex
+	replicationFactor int
+	policy            CoherencePolicy
+	ring              *HashRing
+	locks             map[string]*LockState
+	barriers          map[string]*BarrierState
+	conds             map[string]*CondState
+	pgWriters         map[int][]int
+	pgIntervalWriters map[int][]int
+	shipping          bool
+	readLease         time.Duration
+	pgLeases          map[int]map[int]time.Time
+	staleAcks         map[[2]int]int
+	ownershipWindow   time.Duration
+	pgAcquired        map[int]time.Time
+	pgTransfers       map[int]int
+	pgDeferrals       map[int]int
+	ownerCopySets     bool
+	migratory         bool
+	pgMigratory       map[int]bool
+	pgLastReader      map[int]int
+	pgMigratoryHits   map[int]int
+	pgMigratoryGrants map[int]int
+	outbox            *Outbox
+	diagMu            sync.Mutex
+	handling          *Message
+	handlingSince     time.Time
+	msgReq            chan Message
+	msgRes            chan Message
+	nodeFailChan      chan NodeFailure
+	pauseChan         chan chan int
 }
 
 /*
@@ -27,14 +297,50 @@ Struct to Construct a Node Instance
 */
 type Node struct {
 	id            int
+	mode          ManagerMode
 	cm            *CentralManager
+	managers      map[int]*CentralManager
+	ring          *HashRing
 	nodes         map[int]*Node
-	nodeWaitGroup *sync.WaitGroup
 	pgAccess      map[int]Permission
 	pgContent     map[int]string
+	pgReplica     map[int]string
+	probOwner     map[int]int
+	pgCopySet     map[int][]int
+	pgServed      map[int]map[int]int
+	requests      int
+	broadcasting  *Message
+	chainLengths  []int
+	consistency   ConsistencyModel
+	inInterval    bool
+	pgTwin        map[int]string
+	pgDiffs       map[int]PageDiff
+	lockPages     map[string][]int
+	lockRenewals  map[string]chan int
+	pgUpdates     map[int]func(string) string
+	rmwMu         sync.Mutex
+	readLease     time.Duration
+	pgLeases      map[int]time.Time
+	upgrade       bool
+	blindWrites   bool
+	prefetch      int
+	prefetched    map[int]bool
+	writerCopies  map[int]bool
+	migrated      map[int]string
+	prefetchCount int
+	prefetchHits  int
+	outbox        *Outbox
+	replies       chan Message
+	ops           chan LocalOp
+	stateMu       sync.Mutex
+	inOp          bool
+	opMu          sync.Mutex
+	current       *OpHandle
+	waiting       int32
 	writeToPg     string
 	msgReq        chan Message
 	msgRes        chan Message
+	killChan      chan int
 }
 
 /*
@@ -46,6 +352,95 @@ type Message struct {
 	msgType     MessageType
 	page        int
 	content     string
+	copySet     []int
+	hops        int
+	diff        PageDiff
+	lock        string
+	pages       map[int]string
+	barrier     string
+	cond        string
+	count       int
+	rmw         bool
+	blind       bool
+	unwritten   bool
+	writer      bool
+	seq         int64
+	request     int
+	served      map[int]int
+	op          string
+	arg         string
+	result      string
+}
+
+/*
+Struct to Construct the State of a named Lock at CM, its Holder (0 when free), the FIFO queue of waiters, when the Lease runs out
+and under entry consistency the latest Content of the Pages bound to it
+*/
+type LockState struct {
+	holder int
+	queue  []int
+	expiry time.Time
+	pages  map[int]string
+}
+
+/*
+Struct to Construct the State of a named Barrier at CM, how many Nodes it waits for and the Nodes that arrived so far
+*/
+type BarrierState struct {
+	count   int
+	arrived []int
+}
+
+/*
+Struct to Construct the State of a named Cond at CM, the Lock it is used with and the Nodes waiting on it in FIFO order
+*/
+type CondState struct {
+	lock    string
+	waiters []int
+}
+
+/*
+Struct to Construct the Failure of a Node reported to a CM, done is closed once the CM has handled it
+*/
+type NodeFailure struct {
+	id   int
+	done chan int
+}
+
+/*
+Struct to Construct a Transaction of a Node over a set of Pages, its writes are buffered until Commit
+*/
+type Transaction struct {
+	node    *Node
+	pages   []int
+	writes  map[int]string
+	aborted bool
+}
+
+/*
+Struct to Construct a Consistent Hashing Ring that assigns Pages to CM Shards
+*/
+type HashRing struct {
+	mu     sync.RWMutex
+	points []uint32
+	owners map[uint32]int
+	shards map[int]*CentralManager
+}
+
+/*
+Struct to Construct a Run of changed bytes in a Page Diff
+*/
+type DiffRun struct {
+	offset int
+	data   string
+}
+
+/*
+Struct to Construct the Diff between a Page and its Twin, the new length of the Page and the Runs that changed
+*/
+type PageDiff struct {
+	length int
+	runs   []DiffRun
 }
 
 /*
@@ -60,12 +455,42 @@ const (
 	READACK
 	WRITEACK
 	INVALIDATEACK
+	REPLICATEACK
+	UPDATEACK
+	DIFFREQ
+	DIFFACK
+	LOCKREQ
+	TRYLOCKREQ
+	UNLOCKREQ
+	BINDREQ
+	BARRIERREQ
+	CONDWAITREQ
+	CONDSIGNALREQ
+	CONDBROADCASTREQ
+	APPLYREQ
+	SHIPACK
+	UPGRADEREQ
+	LOCKRENEWREQ
 	//Central Manager to Node Message Types
 	READFWD
 	WRITEFWD
 	INVALIDATE
 	READOWNERNIL
 	WRITEOWNERNIL
+	REPLICATE
+	PROMOTE
+	UPDATE
+	WRITEUPDATED
+	DIFFFWD
+	DIFFAPPLIED
+	LOCKGRANT
+	LOCKBUSY
+	BINDACK
+	BARRIERRELEASE
+	SHIPFWD
+	APPLYRESULT
+	UPGRADEGRANT
+	CONTENTREQ
 	//Node to Node
 	READPG
 	WRITEPG
@@ -81,6 +506,54 @@ const (
 	READWRITE
 )
 
+/*
+CoherencePolicy Enum for what a CM does to the Copies of a Page on a write
+WRITE_INVALIDATE: Copies are invalidated and ownership moves to the writer
+WRITE_UPDATE: the new Content is pushed to the Owner and every Copy holder, ownership stays in place
+*/
+type CoherencePolicy int
+
+const (
+	WRITE_INVALIDATE CoherencePolicy = iota
+	WRITE_UPDATE
+)
+
+/*
+ConsistencyModel Enum for when a Node's writes become visible to other Nodes
+SEQUENTIAL: every write goes through the protocol at once
+RELEASE: writes between Acquire and Release are made locally against a Twin and only propagated as Diffs at Release
+MULTIPLE_WRITER: like RELEASE, but at Release only the Diff is sent to the Owner and merged there, the Page does not move
+ENTRY: Pages bound to a Lock travel with the Lock, they are brought up to date at Lock and sent back at Unlock
+*/
+type ConsistencyModel int
+
+const (
+	SEQUENTIAL ConsistencyModel = iota
+	RELEASE
+	MULTIPLE_WRITER
+	ENTRY
+)
+
+/*
+ManagerMode Enum for the Page Directory Layout
+CENTRALIZED: a single CM (id 0) manages every page
+FIXED_DISTRIBUTED: every Node hosts a CM with its own id, page p is managed by the CM on Node (p mod N) + 1
+DYNAMIC_DISTRIBUTED: there is no CM, Requests follow each Node's probable owner of the Page until they reach the Owner
+BROADCAST: there is no CM, Requests are sent to every Node and only the Owner answers
+SHARDED: SHARD_COUNT CMs split the pages between them by consistent hashing, Shards can be added and removed online
+IMPROVED_CENTRALIZED: a single CM (id 0) only tracks the Owner of every page, the Owner keeps the Copy Set and the writer invalidates it
+*/
+type ManagerMode int
+
+const (
+	CENTRALIZED ManagerMode = iota
+	FIXED_DISTRIBUTED
+	DYNAMIC_DISTRIBUTED
+	BROADCAST
+	SHARDED
+	IMPROVED_CENTRALIZED
+)
+
 func (m MessageType) String() string {
 	return [...]string{
 		"READREQ",
@@ -88,11 +561,41 @@ func (m MessageType) String() string {
 		"READACK",
 		"WRITEACK",
 		"INVALIDATEACK",
+		"REPLICATEACK",
+		"UPDATEACK",
+		"DIFFREQ",
+		"DIFFACK",
+		"LOCKREQ",
+		"TRYLOCKREQ",
+		"UNLOCKREQ",
+		"BINDREQ",
+		"BARRIERREQ",
+		"CONDWAITREQ",
+		"CONDSIGNALREQ",
+		"CONDBROADCASTREQ",
+		"APPLYREQ",
+		"SHIPACK",
+		"UPGRADEREQ",
+		"LOCKRENEWREQ",
 		"READFWD",
 		"WRITEFWD",
 		"INVALIDATE",
 		"READOWNERNIL",
 		"WRITEOWNERNIL",
+		"REPLICATE",
+		"PROMOTE",
+		"UPDATE",
+		"WRITEUPDATED",
+		"DIFFFWD",
+		"DIFFAPPLIED",
+		"LOCKGRANT",
+		"LOCKBUSY",
+		"BINDACK",
+		"BARRIERRELEASE",
+		"SHIPFWD",
+		"APPLYRESULT",
+		"UPGRADEGRANT",
+		"CONTENTREQ",
 		"READPG",
 		"WRITEPG",
 	}[m]
@@ -105,20 +608,74 @@ func (p Permission) String() string {
 	}[p]
 }
 
+func (c CoherencePolicy) String() string {
+	return [...]string{
+		"WRITE INVALIDATE",
+		"WRITE UPDATE",
+	}[c]
+}
+
+func (c ConsistencyModel) String() string {
+	return [...]string{
+		"SEQUENTIAL",
+		"RELEASE",
+		"MULTIPLE WRITER",
+		"ENTRY",
+	}[c]
+}
+
+func (m ManagerMode) String() string {
+	return [...]string{
+		"CENTRALIZED",
+		"FIXED DISTRIBUTED",
+		"DYNAMIC DISTRIBUTED",
+		"BROADCAST",
+		"SHARDED",
+		"IMPROVED CENTRALIZED",
+	}[m]
+}
+
 /*
 Function to Construct a New Node for the Ivy Protocol
 */
-func NewNode(id int, cm CentralManager) *Node {
+func NewNode(id int, cm *CentralManager) *Node {
 	node := Node{
-		id:            id,
-		cm:            &cm,
-		nodes:         make(map[int]*Node),
-		nodeWaitGroup: &sync.WaitGroup{},
-		pgAccess:      make(map[int]Permission),
-		pgContent:     make(map[int]string),
-		writeToPg:     "",
-		msgReq:        make(chan Message),
-		msgRes:        make(chan Message),
+		id:           id,
+		mode:         CENTRALIZED,
+		cm:           cm,
+		nodes:        make(map[int]*Node),
+		pgAccess:     make(map[int]Permission),
+		pgContent:    make(map[int]string),
+		pgReplica:    make(map[int]string),
+		probOwner:    make(map[int]int),
+		pgCopySet:    make(map[int][]int),
+		pgServed:     make(map[int]map[int]int),
+		consistency:  CONSISTENCY_MODEL,
+		pgTwin:       make(map[int]string),
+		pgDiffs:      make(map[int]PageDiff),
+		lockPages:    make(map[string][]int),
+		lockRenewals: make(map[string]chan int),
+		pgUpdates:    make(map[int]func(string) string),
+		readLease:    READ_LEASE,
+		pgLeases:     make(map[int]time.Time),
+		upgrade:      READ_UPGRADE,
+		blindWrites:  BLIND_WRITES,
+		prefetch:     PREFETCH_PAGES,
+		prefetched:   make(map[int]bool),
+		writerCopies: make(map[int]bool),
+		migrated:     make(map[int]string),
+		writeToPg:    "",
+		msgReq:       make(chan Message),
+		msgRes:       make(chan Message),
+		replies:      make(chan Message),
+		ops:          make(chan LocalOp),
+		killChan:     make(chan int),
+	}
+
+	if ORDERED_LINKS {
+		node.outbox = NewOutbox()
+		openMailbox(node.msgReq)
+		openMailbox(node.msgRes)
 	}
 
 	return &node
@@ -127,418 +684,4806 @@ func NewNode(id int, cm CentralManager) *Node {
 /*
 Function to Construct a New Central Manager for the Ivy Protocol
 */
-func NewCM() *CentralManager {
+func NewCM(id int) *CentralManager {
 	cm := CentralManager{
-		nodes:       make(map[int]*Node),
-		cmWaitGroup: &sync.WaitGroup{},
-		pgOwner:     make(map[int]int),
-		pgCopies:    make(map[int][]int),
-		msgReq:      make(chan Message),
-		msgRes:      make(chan Message),
+		id:                id,
+		nodes:             make(map[int]*Node),
+		pgOwner:           make(map[int]int),
+		pgCopies:          make(map[int][]int),
+		pgBackups:         make(map[int][]int),
+		pgPolicy:          make(map[int]CoherencePolicy),
+		replicationFactor: REPLICATION_FACTOR,
+		policy:            COHERENCE_POLICY,
+		locks:             make(map[string]*LockState),
+		barriers:          make(map[string]*BarrierState),
+		conds:             make(map[string]*CondState),
+		pgWriters:         make(map[int][]int),
+		pgIntervalWriters: make(map[int][]int),
+		shipping:          FUNCTION_SHIPPING,
+		readLease:         READ_LEASE,
+		pgLeases:          make(map[int]map[int]time.Time),
+		staleAcks:         make(map[[2]int]int),
+		ownershipWindow:   OWNERSHIP_WINDOW,
+		pgAcquired:        make(map[int]time.Time),
+		pgTransfers:       make(map[int]int),
+		pgDeferrals:       make(map[int]int),
+		migratory:         MIGRATORY_DETECTION,
+		pgMigratory:       make(map[int]bool),
+		pgLastReader:      make(map[int]int),
+		pgMigratoryHits:   make(map[int]int),
+		pgMigratoryGrants: make(map[int]int),
+		msgReq:            make(chan Message),
+		msgRes:            make(chan Message),
+		nodeFailChan:      make(chan NodeFailure),
+		pauseChan:         make(chan chan int),
+	}
+	if ORDERED_LINKS {
+		cm.outbox = NewOutbox()
+		openMailbox(cm.msgReq)
+		openMailbox(cm.msgRes)
 	}
 	return &cm
 }
 
 /*
-Function to Check if a given ID is part of an Array
+Function to record a Message as In Flight and return its sequence number
 */
-func inArray(id int, array []int) bool {
-	for _, item := range array {
-		if item == id {
-			return true
-		}
-	}
-	return false
+func track(msg Message, from string, to string) int64 {
+	seq := atomic.AddInt64(&messageSeq, 1)
+	inFlightMu.Lock()
+	inFlight[seq] = InFlight{msg: msg, from: from, to: to, sentAt: time.Now()}
+	inFlightMu.Unlock()
+	markProgress()
+	return seq
 }
 
 /*
-Function to Construct a Message that's passed between Nodes and CM
+Function to record that the receiver took a Message
 */
-func createMessage(msgType MessageType, senderId int, requesterId int, page int, content string) *Message {
-	msg := Message{
-		msgType:     msgType,
-		senderId:    senderId,
-		requesterId: requesterId,
-		page:        page,
-		content:     content,
-	}
+func delivered(seq int64) {
+	inFlightMu.Lock()
+	delete(inFlight, seq)
+	inFlightMu.Unlock()
+	markProgress()
+}
 
-	return &msg
+/*
+Function to record that the protocol made progress
+*/
+func markProgress() {
+	atomic.StoreInt64(&lastProgress, time.Now().UnixNano())
 }
 
 /*
-Function to Print the State of a Central Manager
+Function to Construct the empty Outbox of a CM or Node
 */
-func (cm *CentralManager) PrintState() {
-	fmt.Printf("**************************************************\n  CENTRAL MANAGER STATE  \n**************************************************\n")
-	for page, owner := range cm.pgOwner {
-		fmt.Printf("> Page: %d, Owner: %d :: Access Type: %s , Copies: %d\n", page, owner, cm.nodes[owner].pgAccess[page], cm.pgCopies[page])
+func NewOutbox() *Outbox {
+	outbox := Outbox{
+		due:   make(map[string]time.Time),
+		slots: make(chan int, OUTBOX_SIZE),
+		wake:  make(chan int, 1),
 	}
+	return &outbox
 }
 
 /*
-Function to send a Message that's passed between Nodes and CM
+Function to put a Message for a receiver in the Outbox, it is never due before the last Message to the same receiver
+so the receiver gets them in the order they were sent, the sender waits while the Outbox is full
 */
-func (cm *CentralManager) sendMessage(msg Message, recieverId int) {
-	fmt.Printf("> [CM] Sending Message of type %s to Node %d\n", msg.msgType, recieverId)
-	networkDelay := rand.Intn(50)
-	time.Sleep(time.Millisecond * time.Duration(networkDelay))
+func (outbox *Outbox) post(to string, inbox chan Message, msg Message) {
+	if inbox == nil {
+		delivered(msg.seq)
+		return
+	}
+	outbox.slots <- 1
 
-	recieverNode := cm.nodes[recieverId]
-	if msg.msgType == READOWNERNIL || msg.msgType == WRITEOWNERNIL {
-		recieverNode.msgRes <- msg
+	outbox.mu.Lock()
+	if outbox.closed {
+		outbox.mu.Unlock()
+		<-outbox.slots
+		delivered(msg.seq)
+		return
+	}
+	networkDelay := rand.Intn(50)
+	deliverAt := time.Now().Add(time.Millisecond * time.Duration(networkDelay))
+	if deliverAt.Before(outbox.due[to]) {
+		deliverAt = outbox.due[to]
+	}
+	outbox.due[to] = deliverAt
+	i := sort.Search(len(outbox.queue), func(i int) bool { return outbox.queue[i].deliverAt.After(deliverAt) })
+	outbox.queue = append(outbox.queue, Envelope{})
+	copy(outbox.queue[i+1:], outbox.queue[i:])
+	outbox.queue[i] = Envelope{msg: msg, inbox: inbox, deliverAt: deliverAt, slots: outbox.slots}
+	if !outbox.active {
+		outbox.active = true
+		go outbox.drain()
 	} else {
-		recieverNode.msgReq <- msg
+		select {
+		case outbox.wake <- 1:
+		default:
+		}
 	}
+	outbox.mu.Unlock()
 }
 
 /*
-Function to handle Incoming Read Request Msgs at CM
+Function to hand the Messages of an Outbox to their Mailboxes once each is due, the goroutine ends when the Outbox is empty
 */
-func (cm *CentralManager) handleReadReq(msg Message) {
-	page := msg.page
-	requesterId := msg.requesterId
+func (outbox *Outbox) drain() {
+	for {
+		outbox.mu.Lock()
+		if len(outbox.queue) == 0 {
+			outbox.active = false
+			outbox.mu.Unlock()
+			return
+		}
+		envelope := outbox.queue[0]
+		if wait := time.Until(envelope.deliverAt); wait > 0 {
+			outbox.mu.Unlock()
+			// A Message posted meanwhile may be due sooner
+			select {
+			case <-time.After(wait):
+			case <-outbox.wake:
+			}
+			continue
+		}
+		outbox.queue = outbox.queue[1:]
+		// Handed over under the lock, so once the Outbox is closed nothing reaches a Mailbox any more
+		openMailbox(envelope.inbox).put(envelope)
+		outbox.mu.Unlock()
+	}
+}
 
-	_, exists := cm.pgOwner[page]
-	if !exists {
-		replyMsg := createMessage(READOWNERNIL, 0, requesterId, page, "")
-		go cm.sendMessage(*replyMsg, requesterId)
-		responseMsg := <-cm.msgRes
-		fmt.Printf("> [CM] Recieved Message of type %s from Node %d\n", responseMsg.msgType, responseMsg.senderId)
-		cm.cmWaitGroup.Done()
-		return
+/*
+Function to close an Outbox, the Messages still in it are dropped and so is anything posted to it later
+*/
+func (outbox *Outbox) close() {
+	outbox.mu.Lock()
+	outbox.closed = true
+	dropped := outbox.queue
+	outbox.queue = nil
+	outbox.mu.Unlock()
+	for _, envelope := range dropped {
+		envelope.release()
 	}
+}
 
-	pgOwner := cm.pgOwner[page]
-	pgCopySet := cm.pgCopies[page]
+/*
+Function to give back the Outbox slot of an Envelope once its Message was taken by the receiver or dropped
+*/
+func (envelope Envelope) release() {
+	delivered(envelope.msg.seq)
+	<-envelope.slots
+}
 
-	replyMsg := createMessage(READFWD, 0, requesterId, page, "")
-	if !inArray(requesterId, pgCopySet) {
-		pgCopySet = append(pgCopySet, requesterId)
+/*
+Function to get the Mailbox of a receiving channel, the first call starts the goroutine that feeds the channel from it
+*/
+func openMailbox(inbox chan Message) *Mailbox {
+	mailboxesMu.Lock()
+	defer mailboxesMu.Unlock()
+	mailbox, exists := mailboxes[inbox]
+	if !exists {
+		mailbox = &Mailbox{ready: make(chan int, 1), done: make(chan int)}
+		mailboxes[inbox] = mailbox
+		go mailbox.pump(inbox)
 	}
-	go cm.sendMessage(*replyMsg, pgOwner)
-	responseMsg := <-cm.msgRes
-	fmt.Printf("> [CM] Recieved Message of type %s from Node %d\n", responseMsg.msgType, responseMsg.senderId)
-	cm.pgCopies[page] = pgCopySet
-	cm.cmWaitGroup.Done()
+	return mailbox
 }
 
 /*
-Function to handle Incoming Write Request Msgs at CM
+Function to close the Mailbox of a receiving channel, its goroutine stops and drops the Messages still waiting in it
 */
-func (cm *CentralManager) handleWriteReq(msg Message) {
-	page := msg.page
-	requesterId := msg.requesterId
-
-	_, exists := cm.pgOwner[page]
+func closeMailbox(inbox chan Message) {
+	mailboxesMu.Lock()
+	mailbox, exists := mailboxes[inbox]
+	delete(mailboxes, inbox)
+	mailboxesMu.Unlock()
 	if !exists {
-		cm.pgOwner[page] = requesterId
-		replyMsg := createMessage(WRITEOWNERNIL, 0, requesterId, page, "")
-		go cm.sendMessage(*replyMsg, requesterId)
-		responseMsg := <-cm.msgRes
-		fmt.Printf("> [CM] Recieved Message of type %s from Node %d\n", responseMsg.msgType, responseMsg.senderId)
-		cm.cmWaitGroup.Done()
 		return
 	}
+	mailbox.mu.Lock()
+	mailbox.closed = true
+	mailbox.mu.Unlock()
+	close(mailbox.done)
+}
 
-	pgOwner := cm.pgOwner[page]
-	pgCopySet := cm.pgCopies[page]
-
-	invalidationMsg := createMessage(INVALIDATE, 0, requesterId, page, "")
-	invalidationMsgCount := len(pgCopySet)
-
-	for _, nodeid := range pgCopySet {
-		go cm.sendMessage(*invalidationMsg, nodeid)
+/*
+Function to leave a Message in a Mailbox without waiting for the receiver
+*/
+func (mailbox *Mailbox) put(envelope Envelope) {
+	mailbox.mu.Lock()
+	if mailbox.closed {
+		mailbox.mu.Unlock()
+		envelope.release()
+		return
 	}
-
-	for i := 0; i < invalidationMsgCount; i++ {
-		msg := <-cm.msgRes
-		fmt.Printf("> [CM] Recieved Message of type %s from Node %d\n", msg.msgType, msg.senderId)
+	mailbox.queue = append(mailbox.queue, envelope)
+	mailbox.mu.Unlock()
+	select {
+	case mailbox.ready <- 1:
+	default:
 	}
-
-	responseMsg := createMessage(WRITEFWD, 0, requesterId, page, "")
-	go cm.sendMessage(*responseMsg, pgOwner)
-	writeAckMsg := <-cm.msgRes
-	fmt.Printf("> [CM] Recieved Message of type %s from Node %d\n", writeAckMsg.msgType, writeAckMsg.senderId)
-	cm.pgOwner[page] = requesterId
-	cm.pgCopies[page] = []int{}
-	cm.cmWaitGroup.Done()
 }
 
 /*
-Function to handle Incoming Msgs at CM
+Function to feed the receiving channel from its Mailbox in the order the Messages were left there, the Outbox slot of
+a Message is given back once the receiver took it
 */
-func (cm *CentralManager) handleIncomingMessages() {
+func (mailbox *Mailbox) pump(inbox chan Message) {
 	for {
-		reqMsg := <-cm.msgReq
-		fmt.Printf("> [CM] Recieved Message of type %s from Node %d\n", reqMsg.msgType, reqMsg.senderId)
-		switch reqMsg.msgType {
-		case READREQ:
-			cm.handleReadReq(reqMsg)
-		case WRITEREQ:
-			cm.handleWriteReq(reqMsg)
+		mailbox.mu.Lock()
+		if mailbox.closed {
+			dropped := mailbox.queue
+			mailbox.queue = nil
+			mailbox.mu.Unlock()
+			for _, envelope := range dropped {
+				envelope.release()
+			}
+			return
 		}
+		if len(mailbox.queue) == 0 {
+			mailbox.mu.Unlock()
+			select {
+			case <-mailbox.ready:
+			case <-mailbox.done:
+			}
+			continue
+		}
+		envelope := mailbox.queue[0]
+		mailbox.queue = mailbox.queue[1:]
+		mailbox.mu.Unlock()
+
+		select {
+		case envelope.inbox <- envelope.msg:
+		case <-mailbox.done:
+		}
+		envelope.release()
 	}
 }
 
 /*
-Function to send messages at Node
+Function to shut down a Cluster a Benchmark is done with, the Outboxes of its CMs and Nodes drop whatever is still sent,
+the goroutines feeding their channels stop and their Mailboxes are forgotten, so building many Clusters does not keep
+every one of them alive
 */
-func (node *Node) sendMessage(msg Message, recieverId int) {
-	if recieverId != 0 {
-		fmt.Printf("> [Node %d] Sending Message of type %s to Node %d\n", node.id, msg.msgType, recieverId)
-	} else {
-		fmt.Printf("> [Node %d] Sending Message of type %s to CM\n", node.id, msg.msgType)
+func shutdownCluster(managers map[int]*CentralManager, nodeMap map[int]*Node) {
+	inboxes := []chan Message{}
+	for _, cm := range managers {
+		if cm.outbox != nil {
+			cm.outbox.close()
+		}
+		inboxes = append(inboxes, cm.msgReq, cm.msgRes)
 	}
-	networkDelay := rand.Intn(50)
-	time.Sleep(time.Millisecond * time.Duration(networkDelay))
-	if recieverId == 0 {
-		if msg.msgType == READREQ || msg.msgType == WRITEREQ {
-			node.cm.msgReq <- msg
-		} else if msg.msgType == INVALIDATEACK || msg.msgType == READACK || msg.msgType == WRITEACK {
-			node.cm.msgRes <- msg
+	for _, node := range nodeMap {
+		if node.outbox != nil {
+			node.outbox.close()
 		}
-	} else {
-		node.nodes[recieverId].msgRes <- msg
+		inboxes = append(inboxes, node.msgReq, node.msgRes)
+	}
+	for _, inbox := range inboxes {
+		closeMailbox(inbox)
+	}
+	for _, node := range nodeMap {
+		node.stateMu.Lock()
+		for name := range node.lockRenewals {
+			node.stopRenewing(name)
+		}
+		node.stateMu.Unlock()
 	}
 }
 
 /*
-Function to handle Read Forward Msgs at Node
+Function to Check if a given ID is part of an Array
 */
-func (node *Node) handleReadFwd(msg Message) {
-	page := msg.page
-	requesterId := msg.requesterId
-
+func inArray(id int, array []int) bool {
+	for _, item := range array {
+		if item == id {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Function to remove an id from an array
+*/
+func removeId(id int, array []int) []int {
+	remaining := []int{}
+	for _, item := range array {
+		if item != id {
+			remaining = append(remaining, item)
+		}
+	}
+	return remaining
+}
+
+/*
+Function to compute the Diff of a Page's Content against its Twin
+*/
+func computeDiff(twin string, content string) PageDiff {
+	diff := PageDiff{length: len(content)}
+	i := 0
+	for i < len(content) {
+		if i < len(twin) && twin[i] == content[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(content) && (i >= len(twin) || twin[i] != content[i]) {
+			i++
+		}
+		diff.runs = append(diff.runs, DiffRun{offset: start, data: content[start:i]})
+	}
+	return diff
+}
+
+/*
+Function to apply a Diff on top of the current Content of a Page
+*/
+func applyDiff(base string, diff PageDiff) string {
+	buf := []byte(base)
+	for len(buf) < diff.length {
+		buf = append(buf, ' ')
+	}
+	buf = buf[:diff.length]
+	for _, run := range diff.runs {
+		copy(buf[run.offset:], run.data)
+	}
+	return string(buf)
+}
+
+/*
+Function to Construct a Message that's passed between Nodes and CM
+*/
+func createMessage(msgType MessageType, senderId int, requesterId int, page int, content string) *Message {
+	msg := Message{
+		msgType:     msgType,
+		senderId:    senderId,
+		requesterId: requesterId,
+		page:        page,
+		content:     content,
+	}
+
+	return &msg
+}
+
+/*
+Function to get the Name of a Central Manager used in the Logs
+*/
+func (cm *CentralManager) name() string {
+	if cm.id == 0 {
+		return "CM"
+	}
+	return fmt.Sprintf("CM %d", cm.id)
+}
+
+/*
+Function to Print the State of a Central Manager
+*/
+func (cm *CentralManager) PrintState() {
+	if cm.id == 0 {
+		fmt.Printf("**************************************************\n  CENTRAL MANAGER STATE  \n**************************************************\n")
+	} else {
+		fmt.Printf("**************************************************\n  CENTRAL MANAGER %d STATE  \n**************************************************\n", cm.id)
+	}
+	for page, owner := range cm.pgOwner {
+		if cm.replicationFactor > 1 {
+			fmt.Printf("> Page: %d, Owner: %d :: Access Type: %s , Copies: %d , Backups: %d\n", page, owner, cm.nodes[owner].pgAccess[page], cm.pgCopies[page], cm.pgBackups[page])
+		} else {
+			fmt.Printf("> Page: %d, Owner: %d :: Access Type: %s , Copies: %d\n", page, owner, cm.nodes[owner].pgAccess[page], cm.pgCopies[page])
+		}
+		if cm.ownershipWindow > 0 {
+			fmt.Printf(">   Thrashing :: Transfers: %d , Deferred Writes: %d\n", cm.pgTransfers[page], cm.pgDeferrals[page])
+		}
+		if cm.migratory || len(cm.pgMigratory) > 0 {
+			fmt.Printf(">   Migratory: %t :: Reads followed by a Write: %d , Reads granted READWRITE: %d\n", cm.migratoryPage(page), cm.pgMigratoryHits[page], cm.pgMigratoryGrants[page])
+		}
+	}
+	for name, lock := range cm.locks {
+		fmt.Printf("> Lock: %s, Holder: %d :: Waiting: %d\n", name, lock.holder, lock.queue)
+		for page, content := range lock.pages {
+			fmt.Printf(">   Bound Page: %d :: Content: %s\n", page, content)
+		}
+	}
+}
+
+/*
+Function to finish a protocol step a Node started, which completes the Node's Operation once nothing else is pending
+*/
+func (cm *CentralManager) finish(nodeId int) {
+	markProgress()
+	if node, exists := cm.nodes[nodeId]; exists {
+		node.resolve()
+	}
+}
+
+/*
+Function to send a Message without waiting for it, in its own goroutine or through the CM's Outbox
+*/
+func (cm *CentralManager) send(msg Message, recieverId int) {
+	if cm.outbox == nil {
+		go cm.sendMessage(msg, recieverId)
+		return
+	}
+	msg.seq = cm.countMessage(msg, recieverId)
+	cm.outbox.post(fmt.Sprintf("Node %d", recieverId), cm.inbox(msg, recieverId), msg)
+}
+
+/*
+Function to count and log a Message sent by a CM
+*/
+func (cm *CentralManager) countMessage(msg Message, recieverId int) int64 {
+	atomic.AddInt64(&messagesSent, 1)
+	atomic.AddInt64(&managerMessages, 1)
+	atomic.AddInt64(&contentBytesSent, int64(len(msg.content)))
+	fmt.Printf("> [%s] Sending Message of type %s to Node %d\n", cm.name(), msg.msgType, recieverId)
+	return track(msg, cm.name(), fmt.Sprintf("Node %d", recieverId))
+}
+
+/*
+Function to send a Message that's passed between Nodes and CM
+*/
+func (cm *CentralManager) sendMessage(msg Message, recieverId int) {
+	msg.seq = cm.countMessage(msg, recieverId)
+	networkDelay := rand.Intn(50)
+	time.Sleep(time.Millisecond * time.Duration(networkDelay))
+	cm.inbox(msg, recieverId) <- msg
+	delivered(msg.seq)
+}
+
+/*
+Function to get the channel of a Node a Message from a CM goes to
+*/
+func (cm *CentralManager) inbox(msg Message, recieverId int) chan Message {
+	recieverNode := cm.nodes[recieverId]
+	if msg.msgType == READOWNERNIL || msg.msgType == WRITEOWNERNIL || msg.msgType == WRITEUPDATED || msg.msgType == DIFFAPPLIED || msg.msgType == LOCKGRANT || msg.msgType == LOCKBUSY || msg.msgType == BINDACK || msg.msgType == BARRIERRELEASE || msg.msgType == APPLYRESULT || msg.msgType == UPGRADEGRANT {
+		return recieverNode.msgRes
+	}
+	return recieverNode.msgReq
+}
+
+/*
+Function to handle Incoming Read Request Msgs at CM
+*/
+func (cm *CentralManager) handleReadReq(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+
+	_, exists := cm.pgOwner[page]
+	if !exists {
+		replyMsg := createMessage(READOWNERNIL, 0, requesterId, page, "")
+		cm.send(*replyMsg, requesterId)
+		responseMsg := cm.awaitResponse()
+		fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), responseMsg.msgType, responseMsg.senderId)
+		cm.finish(requesterId)
+		return
+	}
+
+	pgOwner := cm.pgOwner[page]
+	pgCopySet := cm.pgCopies[page]
+	cm.recordReader(page, requesterId)
+	cm.pgIntervalWriters[page] = removeId(requesterId, cm.pgIntervalWriters[page])
+	if msg.writer {
+		cm.pgIntervalWriters[page] = append(cm.pgIntervalWriters[page], requesterId)
+	}
+	if pgOwner != requesterId && !cm.ownerCopySets && cm.migratoryPage(page) && time.Since(cm.pgAcquired[page]) >= cm.ownershipWindow {
+		cm.grantMigratory(msg)
+		return
+	}
+
+	replyMsg := createMessage(READFWD, 0, requesterId, page, "")
+	replyMsg.pages = cm.prefetchPages(page, requesterId, msg.count)
+	if !cm.ownerCopySets {
+		// Under the Improved Centralized Manager the Owner adds the reader to its own Copy Set
+		if !inArray(requesterId, pgCopySet) {
+			pgCopySet = append(pgCopySet, requesterId)
+		}
+		cm.grantLease(page, requesterId)
+		for prefetchPage := range replyMsg.pages {
+			cm.pgCopies[prefetchPage] = append(cm.pgCopies[prefetchPage], requesterId)
+			cm.grantLease(prefetchPage, requesterId)
+		}
+	}
+	cm.send(*replyMsg, pgOwner)
+	responseMsg := cm.awaitResponse()
+	fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), responseMsg.msgType, responseMsg.senderId)
+	cm.pgCopies[page] = pgCopySet
+	cm.finish(requesterId)
+}
+
+/*
+Function to handle Incoming Write Request Msgs at CM
+*/
+func (cm *CentralManager) handleWriteReq(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+	if cm.deferTransfer(msg) {
+		return
+	}
+	cm.recordWriter(page, requesterId)
+	cm.recordReadThenWrite(page, requesterId)
+
+	_, exists := cm.pgOwner[page]
+	if !exists {
+		cm.pgOwner[page] = requesterId
+		replyMsg := createMessage(WRITEOWNERNIL, 0, requesterId, page, "")
+		replyMsg.count = cm.replicationFactor
+		cm.send(*replyMsg, requesterId)
+		responseMsg := cm.awaitResponse()
+		fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), responseMsg.msgType, responseMsg.senderId)
+		cm.replicatePage(page, responseMsg.content)
+		cm.finish(requesterId)
+		return
+	}
+
+	if cm.policyFor(page) == WRITE_UPDATE && !msg.rmw {
+		cm.handleWriteUpdate(msg)
+		return
+	}
+
+	pgOwner := cm.pgOwner[page]
+	cm.invalidateCopies(page, requesterId, 0)
+
+	responseMsg := createMessage(WRITEFWD, 0, requesterId, page, "")
+	responseMsg.blind = msg.blind
+	responseMsg.count = cm.replicationFactor
+	cm.send(*responseMsg, pgOwner)
+	writeAckMsg := cm.awaitResponse()
+	fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), writeAckMsg.msgType, writeAckMsg.senderId)
+	cm.recordUnwritten(page, pgOwner, writeAckMsg.unwritten)
+	cm.pgOwner[page] = requesterId
+	cm.pgCopies[page] = []int{}
+	cm.pgAcquired[page] = time.Now()
+	cm.pgTransfers[page]++
+	cm.replicatePage(page, writeAckMsg.content)
+	cm.finish(requesterId)
+}
+
+/*
+Function to handle Incoming Upgrade Request Msgs at CM, the Owner and the other Copies are invalidated and the Requester
+keeps its own Copy as the new Owner, a Requester that lost its Copy in the meantime is sent the Page as for a Write Request
+*/
+func (cm *CentralManager) handleUpgradeReq(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+
+	pgOwner, exists := cm.pgOwner[page]
+	if !exists || (pgOwner != requesterId && !inArray(requesterId, cm.pgCopies[page])) || (cm.policyFor(page) == WRITE_UPDATE && !msg.rmw) {
+		msg.msgType = WRITEREQ
+		cm.handleWriteReq(msg)
+		return
+	}
+	if cm.deferTransfer(msg) {
+		return
+	}
+	cm.recordWriter(page, requesterId)
+	cm.recordReadThenWrite(page, requesterId)
+
+	if pgOwner != requesterId {
+		invalidationMsg := createMessage(INVALIDATE, 0, requesterId, page, "")
+		cm.send(*invalidationMsg, pgOwner)
+		invalidateAckMsg := cm.awaitResponse()
+		fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), invalidateAckMsg.msgType, invalidateAckMsg.senderId)
+		cm.recordUnwritten(page, pgOwner, invalidateAckMsg.unwritten)
+	}
+	cm.invalidateCopies(page, requesterId, requesterId)
+
+	grantMsg := createMessage(UPGRADEGRANT, 0, requesterId, page, "")
+	// The Requester only sends the new Content back when the CM keeps Backups of it
+	grantMsg.count = cm.replicationFactor
+	cm.send(*grantMsg, requesterId)
+	writeAckMsg := cm.awaitResponse()
+	fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), writeAckMsg.msgType, writeAckMsg.senderId)
+	cm.pgCopies[page] = []int{}
+	if pgOwner != requesterId {
+		cm.pgOwner[page] = requesterId
+		cm.pgAcquired[page] = time.Now()
+		cm.pgTransfers[page]++
+	}
+	cm.replicatePage(page, writeAckMsg.content)
+	cm.finish(requesterId)
+}
+
+/*
+Function to hold back a Write Request for a Page whose Owner got it less than the ownership window ago,
+the Request is handed back to the CM once the window is over so the Owner gets to use the Page first
+*/
+func (cm *CentralManager) deferTransfer(msg Message) bool {
+	page := msg.page
+	pgOwner, exists := cm.pgOwner[page]
+	if cm.ownershipWindow <= 0 || !exists || pgOwner == msg.requesterId {
+		return false
+	}
+	if cm.policyFor(page) == WRITE_UPDATE && !msg.rmw {
+		return false
+	}
+	remaining := cm.ownershipWindow - time.Since(cm.pgAcquired[page])
+	if remaining <= 0 {
+		return false
+	}
+
+	cm.pgDeferrals[page]++
+	fmt.Printf("> [%s] Node %d got Page %d too recently, holding the Request of Node %d for %dms\n", cm.name(), pgOwner, page, msg.requesterId, remaining.Milliseconds())
+	go func() {
+		time.Sleep(remaining)
+		cm.msgReq <- msg
+	}()
+	return true
+}
+
+/*
+Function to start the Read Lease of a Node on a Page
+*/
+func (cm *CentralManager) grantLease(page int, nodeId int) {
+	if cm.readLease <= 0 {
+		return
+	}
+	if _, exists := cm.pgLeases[page]; !exists {
+		cm.pgLeases[page] = make(map[int]time.Time)
+	}
+	cm.pgLeases[page][nodeId] = time.Now().Add(cm.readLease)
+}
+
+/*
+Function to choose the Pages after a faulting Page that are sent along with it, only Pages of the same Owner
+that the Requester has no Copy of, so one Read Forward and one Read Page cover all of them
+*/
+func (cm *CentralManager) prefetchPages(page int, requesterId int, count int) map[int]string {
+	if count <= 0 || cm.id != 0 {
+		return nil
+	}
+	pgOwner := cm.pgOwner[page]
+	pages := make(map[int]string)
+	for next := page + 1; next <= page+count; next++ {
+		owner, exists := cm.pgOwner[next]
+		if !exists || owner != pgOwner || inArray(requesterId, cm.pgCopies[next]) {
+			continue
+		}
+		pages[next] = ""
+	}
+	if len(pages) == 0 {
+		return nil
+	}
+	return pages
+}
+
+/*
+Function to remember the last Node that read a Page, a read while another Node holds a Copy means the Page is shared
+and not migratory
+*/
+func (cm *CentralManager) recordReader(page int, nodeId int) {
+	for _, nodeid := range cm.pgCopies[page] {
+		if nodeid != nodeId {
+			cm.pgMigratoryHits[page] = 0
+			break
+		}
+	}
+	cm.pgLastReader[page] = nodeId
+}
+
+/*
+Function to count a write by the Node that last read the Page, a write by any other Node starts the count again
+*/
+func (cm *CentralManager) recordReadThenWrite(page int, nodeId int) {
+	if reader, exists := cm.pgLastReader[page]; exists && reader == nodeId {
+		cm.pgMigratoryHits[page]++
+	} else {
+		cm.pgMigratoryHits[page] = 0
+	}
+	delete(cm.pgLastReader, page)
+}
+
+/*
+Function to start the count again when the Owner gave up a Page it was granted READWRITE on a Read without writing it,
+the Page is being read rather than passed from writer to writer
+*/
+func (cm *CentralManager) recordUnwritten(page int, nodeId int, unwritten bool) {
+	if !unwritten {
+		return
+	}
+	fmt.Printf("> [%s] Node %d never wrote Page %d it was granted on a Read, counting Reads followed by a Write again\n", cm.name(), nodeId, page)
+	cm.pgMigratoryHits[page] = 0
+}
+
+/*
+Function to Check if a Page is migratory, a per Page override wins over what the CM detected
+*/
+func (cm *CentralManager) migratoryPage(page int) bool {
+	if migratory, exists := cm.pgMigratory[page]; exists {
+		return migratory
+	}
+	return cm.migratory && cm.pgMigratoryHits[page] >= MIGRATORY_THRESHOLD
+}
+
+/*
+Function to override the migratory detection of a single Page
+*/
+func (cm *CentralManager) SetPageMigratory(page int, migratory bool) {
+	cm.pgMigratory[page] = migratory
+}
+
+/*
+Function to answer a Read Request for a migratory Page with write ownership, the Copies are invalidated
+and the Owner sends the Page to the reader as for a Write Request
+*/
+func (cm *CentralManager) grantMigratory(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+	pgOwner := cm.pgOwner[page]
+	fmt.Printf("> [%s] Page %d is migratory, granting READWRITE to Node %d on its Read\n", cm.name(), page, requesterId)
+
+	cm.invalidateCopies(page, requesterId, requesterId)
+
+	writeFwdMsg := createMessage(WRITEFWD, 0, requesterId, page, "")
+	writeFwdMsg.count = cm.replicationFactor
+	cm.send(*writeFwdMsg, pgOwner)
+	writeAckMsg := cm.awaitResponse()
+	fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), writeAckMsg.msgType, writeAckMsg.senderId)
+	cm.recordUnwritten(page, pgOwner, writeAckMsg.unwritten)
+	cm.pgOwner[page] = requesterId
+	cm.pgCopies[page] = []int{}
+	cm.pgAcquired[page] = time.Now()
+	cm.pgTransfers[page]++
+	cm.pgMigratoryGrants[page]++
+	cm.replicatePage(page, writeAckMsg.content)
+	cm.finish(requesterId)
+}
+
+/*
+Function to get the Coherence Policy of a Page, a per Page override wins over the CM wide policy
+*/
+func (cm *CentralManager) policyFor(page int) CoherencePolicy {
+	cm.policyMu.RLock()
+	defer cm.policyMu.RUnlock()
+	if policy, exists := cm.pgPolicy[page]; exists {
+		return policy
+	}
+	return cm.policy
+}
+
+/*
+Function to override the Coherence Policy of a single Page, write update needs the CM's list of Copies
+*/
+func (cm *CentralManager) SetPagePolicy(page int, policy CoherencePolicy) {
+	if cm.ownerCopySets && policy == WRITE_UPDATE {
+		fmt.Printf("> [%s] Cannot use %s for Page %d, the Owner keeps the Copy Set\n", cm.name(), policy, page)
+		return
+	}
+	cm.policyMu.Lock()
+	defer cm.policyMu.Unlock()
+	cm.pgPolicy[page] = policy
+}
+
+/*
+Function to handle a Write Request under Write Update, the new Content is pushed to the Owner and every Copy holder
+and the writer is left with a READONLY Copy
+*/
+func (cm *CentralManager) handleWriteUpdate(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+	pgOwner := cm.pgOwner[page]
+	pgCopySet := cm.pgCopies[page]
+
+	updateMsg := createMessage(UPDATE, 0, requesterId, page, msg.content)
+	updateMsgCount := 0
+	for _, nodeid := range append([]int{pgOwner}, pgCopySet...) {
+		if nodeid != requesterId {
+			cm.send(*updateMsg, nodeid)
+			updateMsgCount++
+		}
+	}
+	replyMsg := createMessage(WRITEUPDATED, 0, requesterId, page, msg.content)
+	cm.send(*replyMsg, requesterId)
+
+	for i := 0; i < updateMsgCount+1; i++ {
+		msg := cm.awaitResponse()
+		fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), msg.msgType, msg.senderId)
+	}
+
+	if requesterId != pgOwner && !inArray(requesterId, pgCopySet) {
+		pgCopySet = append(pgCopySet, requesterId)
+	}
+	cm.pgCopies[page] = pgCopySet
+	cm.replicatePage(page, msg.content)
+	cm.finish(requesterId)
+}
+
+/*
+Function to handle Incoming Diff Request Msgs at CM, the Diff is merged at the Owner which keeps the Page, a Page without
+an Owner goes to the writer. Only the readers' Copies are invalidated, the writers of the Page keep theirs because their
+own Diffs are merged at the Owner too, and the requester gets the merged Content back
+*/
+func (cm *CentralManager) handleDiffReq(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+
+	pgOwner, exists := cm.pgOwner[page]
+	if !exists {
+		pgOwner = requesterId
+		cm.pgOwner[page] = pgOwner
+	}
+	if requesterId != pgOwner && !inArray(requesterId, cm.pgIntervalWriters[page]) {
+		cm.pgIntervalWriters[page] = append(cm.pgIntervalWriters[page], requesterId)
+	}
+
+	kept := []int{}
+	readers := []int{}
+	for _, nodeid := range cm.pgCopies[page] {
+		if nodeid != pgOwner && inArray(nodeid, cm.pgIntervalWriters[page]) {
+			kept = append(kept, nodeid)
+		} else {
+			readers = append(readers, nodeid)
+		}
+	}
+	cm.pgCopies[page] = readers
+	cm.invalidateCopies(page, requesterId, pgOwner)
+	if requesterId != pgOwner && !inArray(requesterId, kept) {
+		kept = append(kept, requesterId)
+	}
+
+	diffFwdMsg := createMessage(DIFFFWD, 0, requesterId, page, "")
+	diffFwdMsg.diff = msg.diff
+	diffFwdMsg.copySet = kept
+	cm.send(*diffFwdMsg, pgOwner)
+	diffAckMsg := cm.awaitResponse()
+	fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), diffAckMsg.msgType, diffAckMsg.senderId)
+	cm.pgCopies[page] = kept
+	cm.replicatePage(page, diffAckMsg.content)
+
+	replyMsg := createMessage(DIFFAPPLIED, 0, requesterId, page, "")
+	if requesterId != pgOwner {
+		replyMsg.content = diffAckMsg.content
+		replyMsg.writer = true
+	}
+	cm.send(*replyMsg, requesterId)
+	cm.finish(requesterId)
+}
+
+/*
+Function to invalidate every Copy of a Page except the one held by a given Node, with read leases the CM stops waiting
+for a holder's INVALIDATEACK once its Lease has run out, and a Copy whose Lease already ran out is not sent anything,
+a Copy that was given out without a Lease is always invalidated and waited for
+*/
+func (cm *CentralManager) invalidateCopies(page int, requesterId int, except int) {
+	invalidationMsg := createMessage(INVALIDATE, 0, requesterId, page, "")
+	pending := make(map[int]bool)
+	leased := make(map[int]bool)
+	deadline := time.Now()
+	for _, nodeid := range cm.pgCopies[page] {
+		if nodeid == except {
+			continue
+		}
+		if expiry := cm.pgLeases[page][nodeid]; cm.readLease > 0 && !expiry.IsZero() {
+			leased[nodeid] = true
+			if time.Now().After(expiry) {
+				fmt.Printf("> [%s] Lease of Node %d on Page %d already ran out\n", cm.name(), nodeid, page)
+				continue
+			}
+			if expiry.After(deadline) {
+				deadline = expiry
+			}
+		}
+		cm.send(*invalidationMsg, nodeid)
+		pending[nodeid] = true
+	}
+	delete(cm.pgLeases, page)
+
+	var leasesExpired <-chan time.Time
+	if len(leased) > 0 {
+		leasesExpired = time.After(time.Until(deadline))
+	}
+	for len(pending) > 0 {
+		select {
+		case msg := <-cm.msgRes:
+			if msg.msgType == INVALIDATEACK && msg.page == page && pending[msg.senderId] {
+				fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), msg.msgType, msg.senderId)
+				delete(pending, msg.senderId)
+			} else {
+				cm.dropStaleAck(msg)
+			}
+		case <-leasesExpired:
+			leasesExpired = nil
+			for nodeid := range pending {
+				if !leased[nodeid] {
+					continue
+				}
+				fmt.Printf("> [%s] Lease of Node %d on Page %d ran out without an INVALIDATEACK, going ahead\n", cm.name(), nodeid, page)
+				cm.staleAcks[[2]int{nodeid, page}]++
+				delete(pending, nodeid)
+			}
+		}
+	}
+}
+
+/*
+Function to wait for the next response at CM, an INVALIDATEACK that comes in after its Lease ran out is dropped
+*/
+func (cm *CentralManager) awaitResponse() Message {
+	for {
+		msg := <-cm.msgRes
+		if !cm.dropStaleAck(msg) {
+			return msg
+		}
+	}
+}
+
+/*
+Function to drop an INVALIDATEACK the CM already stopped waiting for, returns whether the Message was dropped
+*/
+func (cm *CentralManager) dropStaleAck(msg Message) bool {
+	key := [2]int{msg.senderId, msg.page}
+	if msg.msgType != INVALIDATEACK || cm.staleAcks[key] == 0 {
+		return false
+	}
+	cm.staleAcks[key]--
+	fmt.Printf("> [%s] Dropping late Message of type %s from Node %d for Page %d\n", cm.name(), msg.msgType, msg.senderId, msg.page)
+	return true
+}
+
+/*
+Function to remember the last SHIP_WINDOW Nodes that wrote a Page
+*/
+func (cm *CentralManager) recordWriter(page int, nodeId int) {
+	writers := append(cm.pgWriters[page], nodeId)
+	if len(writers) > SHIP_WINDOW {
+		writers = writers[len(writers)-SHIP_WINDOW:]
+	}
+	cm.pgWriters[page] = writers
+}
+
+/*
+Function to Check if a Page is contended, enough different Nodes wrote it lately that migrating it would thrash
+*/
+func (cm *CentralManager) contended(page int) bool {
+	writers := []int{}
+	for _, nodeid := range cm.pgWriters[page] {
+		if !inArray(nodeid, writers) {
+			writers = append(writers, nodeid)
+		}
+	}
+	return len(writers) >= SHIP_CONTENDERS
+}
+
+/*
+Function to handle Incoming Apply Request Msgs at CM, the Operation is shipped to the Owner of a contended Page
+and the result sent back, otherwise the Page migrates to the caller as an atomic write
+*/
+func (cm *CentralManager) handleApplyReq(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+
+	pgOwner, exists := cm.pgOwner[page]
+	if !cm.shipping || !exists || pgOwner == requesterId || !cm.contended(page) {
+		msg.rmw = true
+		cm.handleWriteReq(msg)
+		return
+	}
+	cm.recordWriter(page, requesterId)
+	fmt.Printf("> [%s] Page %d is contended, shipping Operation %s to Node %d\n", cm.name(), page, msg.op, pgOwner)
+
+	cm.invalidateCopies(page, requesterId, pgOwner)
+
+	shipFwdMsg := createMessage(SHIPFWD, 0, requesterId, page, "")
+	shipFwdMsg.op = msg.op
+	shipFwdMsg.arg = msg.arg
+	// The Owner only sends the new Content back when the CM keeps Backups of it
+	shipFwdMsg.count = cm.replicationFactor
+	cm.send(*shipFwdMsg, pgOwner)
+	shipAckMsg := cm.awaitResponse()
+	fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), shipAckMsg.msgType, shipAckMsg.senderId)
+	cm.pgCopies[page] = []int{}
+	cm.replicatePage(page, shipAckMsg.content)
+
+	replyMsg := createMessage(APPLYRESULT, 0, requesterId, page, "")
+	replyMsg.result = shipAckMsg.result
+	cm.send(*replyMsg, requesterId)
+	cm.finish(requesterId)
+}
+
+/*
+Function to choose the k-1 Backup Holders of a Page, the next live Nodes after the Owner
+*/
+func (cm *CentralManager) chooseBackups(owner int) []int {
+	ids := []int{}
+	for id := range cm.nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	start := sort.SearchInts(ids, owner)
+	backups := []int{}
+	for i := 1; i < len(ids) && len(backups) < cm.replicationFactor-1; i++ {
+		backups = append(backups, ids[(start+i)%len(ids)])
+	}
+	return backups
+}
+
+/*
+Function to push the newly written Content of a Page to its Backup Holders
+*/
+func (cm *CentralManager) replicatePage(page int, content string) {
+	if cm.replicationFactor <= 1 {
+		return
+	}
+
+	backups := cm.chooseBackups(cm.pgOwner[page])
+	replicateMsg := createMessage(REPLICATE, 0, cm.pgOwner[page], page, content)
+	for _, nodeid := range backups {
+		cm.send(*replicateMsg, nodeid)
+	}
+
+	for i := 0; i < len(backups); i++ {
+		msg := cm.awaitResponse()
+		fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), msg.msgType, msg.senderId)
+	}
+	cm.pgBackups[page] = backups
+}
+
+/*
+Function to handle the Failure of a Node at CM, promoting a Backup Holder for every Page the dead Node owned
+*/
+func (cm *CentralManager) handleNodeFailure(deadId int) {
+	fmt.Printf("> [%s] Node %d has failed\n", cm.name(), deadId)
+	delete(cm.nodes, deadId)
+
+	for page, copies := range cm.pgCopies {
+		liveCopies := []int{}
+		for _, nodeid := range copies {
+			if nodeid != deadId {
+				liveCopies = append(liveCopies, nodeid)
+			}
+		}
+		cm.pgCopies[page] = liveCopies
+	}
+
+	for page, owner := range cm.pgOwner {
+		backups := cm.pgBackups[page]
+		if owner != deadId {
+			if inArray(deadId, backups) {
+				// The Owner keeps its access, only a new Backup holder gets its Content
+				cm.replicatePage(page, cm.ownerContent(page, owner))
+			}
+			continue
+		}
+
+		newOwner := 0
+		for _, nodeid := range backups {
+			if nodeid != deadId {
+				newOwner = nodeid
+				break
+			}
+		}
+		if newOwner == 0 {
+			fmt.Printf("> [%s] Page %d had no live Backup Holder, its Content is lost\n", cm.name(), page)
+			delete(cm.pgOwner, page)
+			delete(cm.pgCopies, page)
+			delete(cm.pgBackups, page)
+			continue
+		}
+
+		// The surviving Copies must be gone before the new Owner gets READWRITE and writes over them
+		cm.invalidateCopies(page, newOwner, newOwner)
+		content := cm.promoteOwner(page, newOwner)
+		cm.pgOwner[page] = newOwner
+		cm.pgCopies[page] = []int{}
+		cm.replicatePage(page, content)
+	}
+	for name, lock := range cm.locks {
+		queue := []int{}
+		for _, nodeid := range lock.queue {
+			if nodeid == deadId {
+				cm.finish(nodeid)
+				continue
+			}
+			queue = append(queue, nodeid)
+		}
+		lock.queue = queue
+		if lock.holder == deadId {
+			fmt.Printf("> [%s] Releasing Lock %s held by dead Node %d\n", cm.name(), name, deadId)
+			cm.grantNext(name)
+		}
+	}
+	for _, cond := range cm.conds {
+		waiters := []int{}
+		for _, nodeid := range cond.waiters {
+			if nodeid == deadId {
+				cm.finish(nodeid)
+				continue
+			}
+			waiters = append(waiters, nodeid)
+		}
+		cond.waiters = waiters
+	}
+	for name, barrier := range cm.barriers {
+		if !inArray(deadId, barrier.arrived) {
+			continue
+		}
+		cm.finish(deadId)
+		barrier.arrived = removeId(deadId, barrier.arrived)
+		// The dead Node arrived but will never leave, the Barrier waits for one Node less
+		barrier.count--
+		cm.releaseBarrier(name)
+	}
+}
+
+/*
+Function to collect the Content the Owner of a Page holds without changing its access
+*/
+func (cm *CentralManager) ownerContent(page int, nodeId int) string {
+	contentReqMsg := createMessage(CONTENTREQ, 0, nodeId, page, "")
+	cm.send(*contentReqMsg, nodeId)
+	ackMsg := cm.awaitResponse()
+	fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), ackMsg.msgType, ackMsg.senderId)
+	return ackMsg.content
+}
+
+/*
+Function to make a Node the READWRITE Owner of a Page and collect the Content it holds
+*/
+func (cm *CentralManager) promoteOwner(page int, nodeId int) string {
+	promoteMsg := createMessage(PROMOTE, 0, nodeId, page, "")
+	cm.send(*promoteMsg, nodeId)
+	ackMsg := cm.awaitResponse()
+	fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), ackMsg.msgType, ackMsg.senderId)
+	return ackMsg.content
+}
+
+/*
+Function to get the Lock State of a named Lock at CM, a Lock is created free the first time it is used
+*/
+func (cm *CentralManager) lockState(name string) *LockState {
+	lock, exists := cm.locks[name]
+	if !exists {
+		lock = &LockState{}
+		cm.locks[name] = lock
+	}
+	return lock
+}
+
+/*
+Function to give a Lock to a Node and start its Lease
+*/
+func (cm *CentralManager) grantLock(name string, nodeId int) {
+	lock := cm.lockState(name)
+	lock.holder = nodeId
+	lock.expiry = time.Now().Add(LOCK_LEASE)
+	grantMsg := createMessage(LOCKGRANT, 0, nodeId, lockHome(name), "")
+	grantMsg.lock = name
+	if len(lock.pages) > 0 {
+		grantMsg.pages = make(map[int]string)
+		for page, content := range lock.pages {
+			grantMsg.pages[page] = content
+		}
+	}
+	cm.send(*grantMsg, nodeId)
+	cm.finish(nodeId)
+}
+
+/*
+Function to pass a released Lock to the first Node waiting for it, or mark it free
+*/
+func (cm *CentralManager) grantNext(name string) {
+	lock := cm.lockState(name)
+	lock.holder = 0
+	if len(lock.queue) == 0 {
+		return
+	}
+	next := lock.queue[0]
+	lock.queue = lock.queue[1:]
+	cm.grantLock(name, next)
+}
+
+/*
+Function to handle Incoming Lock Request Msgs at CM, a held Lock queues the Node in FIFO order until it is released
+*/
+func (cm *CentralManager) handleLockReq(msg Message) {
+	lock := cm.lockState(msg.lock)
+	if lock.holder == 0 {
+		cm.grantLock(msg.lock, msg.requesterId)
+		return
+	}
+	fmt.Printf("> [%s] Lock %s is held by Node %d, Node %d is waiting\n", cm.name(), msg.lock, lock.holder, msg.requesterId)
+	lock.queue = append(lock.queue, msg.requesterId)
+}
+
+/*
+Function to handle Incoming Try Lock Request Msgs at CM, a held Lock is answered with LOCKBUSY instead of queueing
+*/
+func (cm *CentralManager) handleTryLockReq(msg Message) {
+	lock := cm.lockState(msg.lock)
+	if lock.holder == 0 {
+		cm.grantLock(msg.lock, msg.requesterId)
+		return
+	}
+	replyMsg := createMessage(LOCKBUSY, 0, msg.requesterId, msg.page, "")
+	replyMsg.lock = msg.lock
+	cm.send(*replyMsg, msg.requesterId)
+	cm.finish(msg.requesterId)
+}
+
+/*
+Function to handle Incoming Lock Renew Request Msgs at CM, the holder's Lease starts again, a Node that lost the Lock is ignored
+*/
+func (cm *CentralManager) handleRenewReq(msg Message) {
+	lock, exists := cm.locks[msg.lock]
+	if !exists || lock.holder != msg.requesterId {
+		fmt.Printf("> [%s] Node %d no longer holds Lock %s, not renewing its Lease\n", cm.name(), msg.requesterId, msg.lock)
+		return
+	}
+	lock.expiry = time.Now().Add(LOCK_LEASE)
+}
+
+/*
+Function to handle Incoming Unlock Request Msgs at CM, an Unlock from a Node whose Lease already ran out is ignored
+*/
+func (cm *CentralManager) handleUnlockReq(msg Message) {
+	lock := cm.lockState(msg.lock)
+	if lock.holder != msg.requesterId {
+		fmt.Printf("> [%s] Node %d no longer holds Lock %s, its Lease ran out\n", cm.name(), msg.requesterId, msg.lock)
+	} else {
+		for page, content := range msg.pages {
+			lock.pages[page] = content
+		}
+		cm.grantNext(msg.lock)
+	}
+	cm.finish(msg.requesterId)
+}
+
+/*
+Function to handle Incoming Bind Request Msgs at CM, the Pages start travelling with the Lock from the Content the binder holds
+*/
+func (cm *CentralManager) handleBindReq(msg Message) {
+	lock := cm.lockState(msg.lock)
+	if lock.pages == nil {
+		lock.pages = make(map[int]string)
+	}
+	for page, content := range msg.pages {
+		lock.pages[page] = content
+	}
+	fmt.Printf("> [%s] Bound %d Pages to Lock %s\n", cm.name(), len(msg.pages), msg.lock)
+
+	replyMsg := createMessage(BINDACK, 0, msg.requesterId, msg.page, "")
+	replyMsg.lock = msg.lock
+	cm.send(*replyMsg, msg.requesterId)
+	cm.finish(msg.requesterId)
+}
+
+/*
+Function to handle Incoming Barrier Request Msgs at CM, the Nodes are held until as many as the Barrier waits for have arrived
+*/
+func (cm *CentralManager) handleBarrierReq(msg Message) {
+	barrier, exists := cm.barriers[msg.barrier]
+	if !exists {
+		barrier = &BarrierState{count: msg.count}
+		cm.barriers[msg.barrier] = barrier
+	}
+	barrier.arrived = append(barrier.arrived, msg.requesterId)
+	fmt.Printf("> [%s] Node %d arrived at Barrier %s (%d/%d)\n", cm.name(), msg.requesterId, msg.barrier, len(barrier.arrived), barrier.count)
+	cm.releaseBarrier(msg.barrier)
+}
+
+/*
+Function to let every Node through a Barrier once all of them have arrived, the Barrier can then be used again
+*/
+func (cm *CentralManager) releaseBarrier(name string) {
+	barrier := cm.barriers[name]
+	if len(barrier.arrived) < barrier.count {
+		return
+	}
+	delete(cm.barriers, name)
+	for _, nodeid := range barrier.arrived {
+		releaseMsg := createMessage(BARRIERRELEASE, 0, nodeid, lockHome(name), "")
+		releaseMsg.barrier = name
+		cm.send(*releaseMsg, nodeid)
+		cm.finish(nodeid)
+	}
+}
+
+/*
+Function to handle Incoming Cond Wait Request Msgs at CM, the Lock is released and the Node waits on the Cond,
+once signalled it queues for the Lock again and is answered with LOCKGRANT
+*/
+func (cm *CentralManager) handleCondWaitReq(msg Message) {
+	lock := cm.lockState(msg.lock)
+	if lock.holder != msg.requesterId {
+		fmt.Printf("> [%s] Node %d waits on Cond %s without holding Lock %s\n", cm.name(), msg.requesterId, msg.cond, msg.lock)
+	} else {
+		for page, content := range msg.pages {
+			lock.pages[page] = content
+		}
+		cm.grantNext(msg.lock)
+	}
+
+	cond, exists := cm.conds[msg.cond]
+	if !exists {
+		cond = &CondState{lock: msg.lock}
+		cm.conds[msg.cond] = cond
+	}
+	cond.waiters = append(cond.waiters, msg.requesterId)
+	fmt.Printf("> [%s] Node %d is waiting on Cond %s\n", cm.name(), msg.requesterId, msg.cond)
+}
+
+/*
+Function to handle Incoming Cond Signal and Broadcast Request Msgs at CM, the first or every waiter queues for the Lock again
+*/
+func (cm *CentralManager) handleCondSignalReq(msg Message) {
+	cond, exists := cm.conds[msg.cond]
+	if exists && len(cond.waiters) > 0 {
+		woken := cond.waiters[:1]
+		if msg.msgType == CONDBROADCASTREQ {
+			woken = cond.waiters
+		}
+		cond.waiters = cond.waiters[len(woken):]
+		fmt.Printf("> [%s] Waking Nodes %d waiting on Cond %s\n", cm.name(), woken, msg.cond)
+
+		lock := cm.lockState(cond.lock)
+		lock.queue = append(lock.queue, woken...)
+		if lock.holder == 0 {
+			cm.grantNext(cond.lock)
+		}
+	}
+	cm.finish(msg.requesterId)
+}
+
+/*
+Function to take back every Lock whose Lease ran out and pass it on to the next waiter
+*/
+func (cm *CentralManager) expireLeases() {
+	now := time.Now()
+	for name, lock := range cm.locks {
+		if lock.holder != 0 && now.After(lock.expiry) {
+			fmt.Printf("> [%s] Lease of Node %d on Lock %s ran out\n", cm.name(), lock.holder, name)
+			cm.grantNext(name)
+		}
+	}
+}
+
+/*
+Function to record the Request the CM is handling, nil once it is done
+*/
+func (cm *CentralManager) setHandling(msg *Message) {
+	cm.diagMu.Lock()
+	cm.handling = msg
+	cm.handlingSince = time.Now()
+	cm.diagMu.Unlock()
+}
+
+/*
+Function to handle Incoming Msgs at CM
+*/
+func (cm *CentralManager) handleIncomingMessages() {
+	leaseTicker := time.NewTicker(LOCK_LEASE / 4)
+	defer leaseTicker.Stop()
+	for {
+		select {
+		case reqMsg := <-cm.msgReq:
+			fmt.Printf("> [%s] Recieved Message of type %s from Node %d\n", cm.name(), reqMsg.msgType, reqMsg.senderId)
+			if cm.ring != nil && cm.ring.shardFor(reqMsg.page) != cm.id {
+				// Sent before the Ring changed, the Page is now managed by another Shard
+				target := cm.ring.managerFor(reqMsg.page)
+				fmt.Printf("> [%s] Forwarding Message of type %s for Page %d to %s\n", cm.name(), reqMsg.msgType, reqMsg.page, target.name())
+				go func() { target.msgReq <- reqMsg }()
+				continue
+			}
+			cm.setHandling(&reqMsg)
+			switch reqMsg.msgType {
+			case READREQ:
+				cm.handleReadReq(reqMsg)
+			case WRITEREQ:
+				cm.handleWriteReq(reqMsg)
+			case DIFFREQ:
+				cm.handleDiffReq(reqMsg)
+			case LOCKREQ:
+				cm.handleLockReq(reqMsg)
+			case TRYLOCKREQ:
+				cm.handleTryLockReq(reqMsg)
+			case UNLOCKREQ:
+				cm.handleUnlockReq(reqMsg)
+			case BINDREQ:
+				cm.handleBindReq(reqMsg)
+			case BARRIERREQ:
+				cm.handleBarrierReq(reqMsg)
+			case CONDWAITREQ:
+				cm.handleCondWaitReq(reqMsg)
+			case CONDSIGNALREQ, CONDBROADCASTREQ:
+				cm.handleCondSignalReq(reqMsg)
+			case APPLYREQ:
+				cm.handleApplyReq(reqMsg)
+			case UPGRADEREQ:
+				cm.handleUpgradeReq(reqMsg)
+			case LOCKRENEWREQ:
+				cm.handleRenewReq(reqMsg)
+			}
+			cm.setHandling(nil)
+		case <-leaseTicker.C:
+			cm.expireLeases()
+		case failure := <-cm.nodeFailChan:
+			cm.handleNodeFailure(failure.id)
+			close(failure.done)
+		case resume := <-cm.pauseChan:
+			<-resume
+		}
+	}
+}
+
+/*
+Function to Construct a New Consistent Hashing Ring for Sharded CMs
+*/
+func NewHashRing() *HashRing {
+	ring := HashRing{
+		points: []uint32{},
+		owners: make(map[uint32]int),
+		shards: make(map[int]*CentralManager),
+	}
+	return &ring
+}
+
+/*
+Function to Hash a Key onto the Ring
+*/
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+/*
+Function to find the Shard owning a Page, the first Shard point clockwise from the Page's hash (caller holds the lock)
+*/
+func (ring *HashRing) lookup(page int) int {
+	h := hashKey(fmt.Sprintf("page-%d", page))
+	i := sort.Search(len(ring.points), func(i int) bool { return ring.points[i] >= h })
+	if i == len(ring.points) {
+		i = 0
+	}
+	return ring.owners[ring.points[i]]
+}
+
+/*
+Function to get the id of the Shard managing a Page
+*/
+func (ring *HashRing) shardFor(page int) int {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.lookup(page)
+}
+
+/*
+Function to get the CM Shard managing a Page
+*/
+func (ring *HashRing) managerFor(page int) *CentralManager {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.shards[ring.lookup(page)]
+}
+
+/*
+Function to place a Shard's virtual points on the Ring (caller holds the lock)
+*/
+func (ring *HashRing) place(cm *CentralManager) {
+	for v := 0; v < SHARD_VIRTUAL_NODES; v++ {
+		point := hashKey(fmt.Sprintf("shard-%d-%d", cm.id, v))
+		ring.points = append(ring.points, point)
+		ring.owners[point] = cm.id
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+	ring.shards[cm.id] = cm
+	cm.ring = ring
+}
+
+/*
+Function to take a Shard's virtual points off the Ring (caller holds the lock)
+*/
+func (ring *HashRing) unplace(id int) {
+	points := []uint32{}
+	for _, point := range ring.points {
+		if ring.owners[point] == id {
+			delete(ring.owners, point)
+		} else {
+			points = append(points, point)
+		}
+	}
+	ring.points = points
+	delete(ring.shards, id)
+}
+
+/*
+Function to change the Shards on the Ring online: every Shard is paused between Requests,
+the Ring is changed and every Directory entry that now hashes to another Shard is handed over before resuming
+*/
+func (ring *HashRing) rebalance(change func()) {
+	ring.mu.RLock()
+	paused := make(map[int]*CentralManager)
+	for id, shard := range ring.shards {
+		paused[id] = shard
+	}
+	ring.mu.RUnlock()
+
+	resumeChans := []chan int{}
+	for _, shard := range paused {
+		resume := make(chan int)
+		shard.pauseChan <- resume
+		resumeChans = append(resumeChans, resume)
+	}
+
+	ring.mu.Lock()
+	change()
+	moved := 0
+	for id, shard := range paused {
+		for page := range shard.pgOwner {
+			if target := ring.lookup(page); target != id {
+				shard.handOver(page, ring.shards[target])
+				moved++
+			}
+		}
+		for page := range shard.pgCopies {
+			if target := ring.lookup(page); target != id {
+				shard.handOver(page, ring.shards[target])
+				moved++
+			}
+		}
+		for name, lock := range shard.locks {
+			if target := ring.lookup(lockHome(name)); target != id {
+				ring.shards[target].locks[name] = lock
+				delete(shard.locks, name)
+				moved++
+			}
+		}
+		for name, barrier := range shard.barriers {
+			if target := ring.lookup(lockHome(name)); target != id {
+				ring.shards[target].barriers[name] = barrier
+				delete(shard.barriers, name)
+				moved++
+			}
+		}
+		for name, cond := range shard.conds {
+			if target := ring.lookup(lockHome(cond.lock)); target != id {
+				ring.shards[target].conds[name] = cond
+				delete(shard.conds, name)
+				moved++
+			}
+		}
+	}
+	fmt.Printf("> [Ring] Rebalanced %d Shards, moved %d Directory entries\n", len(ring.shards), moved)
+	ring.mu.Unlock()
+
+	for _, resume := range resumeChans {
+		close(resume)
+	}
+}
+
+/*
+Function to add a CM Shard to the Ring and start its Message Loop
+*/
+func (ring *HashRing) addShard(cm *CentralManager) {
+	fmt.Printf("> [Ring] Adding %s\n", cm.name())
+	go cm.handleIncomingMessages()
+	ring.rebalance(func() {
+		ring.place(cm)
+	})
+}
+
+/*
+Function to remove a CM Shard from the Ring, it keeps forwarding Requests that were already sent to it
+*/
+func (ring *HashRing) removeShard(id int) {
+	fmt.Printf("> [Ring] Removing CM %d\n", id)
+	ring.rebalance(func() {
+		ring.unplace(id)
+	})
+}
+
+/*
+Function to move the Directory entry of a Page to another CM Shard
+*/
+func (cm *CentralManager) handOver(page int, target *CentralManager) {
+	if owner, exists := cm.pgOwner[page]; exists {
+		target.pgOwner[page] = owner
+	}
+	if copies, exists := cm.pgCopies[page]; exists {
+		target.pgCopies[page] = copies
+	}
+	if backups, exists := cm.pgBackups[page]; exists {
+		target.pgBackups[page] = backups
+	}
+	if policy, exists := cm.pgPolicy[page]; exists {
+		target.SetPagePolicy(page, policy)
+	}
+	if writers, exists := cm.pgWriters[page]; exists {
+		target.pgWriters[page] = writers
+	}
+	if writers, exists := cm.pgIntervalWriters[page]; exists {
+		target.pgIntervalWriters[page] = writers
+	}
+	if acquired, exists := cm.pgAcquired[page]; exists {
+		target.pgAcquired[page] = acquired
+	}
+	target.pgTransfers[page] += cm.pgTransfers[page]
+	target.pgDeferrals[page] += cm.pgDeferrals[page]
+	if migratory, exists := cm.pgMigratory[page]; exists {
+		target.pgMigratory[page] = migratory
+	}
+	if reader, exists := cm.pgLastReader[page]; exists {
+		target.pgLastReader[page] = reader
+	}
+	target.pgMigratoryHits[page] += cm.pgMigratoryHits[page]
+	target.pgMigratoryGrants[page] += cm.pgMigratoryGrants[page]
+	delete(cm.pgMigratory, page)
+	delete(cm.pgLastReader, page)
+	delete(cm.pgMigratoryHits, page)
+	delete(cm.pgMigratoryGrants, page)
+	delete(cm.pgWriters, page)
+	delete(cm.pgIntervalWriters, page)
+	delete(cm.pgAcquired, page)
+	delete(cm.pgTransfers, page)
+	delete(cm.pgDeferrals, page)
+	cm.policyMu.Lock()
+	delete(cm.pgPolicy, page)
+	cm.policyMu.Unlock()
+	delete(cm.pgOwner, page)
+	delete(cm.pgCopies, page)
+	delete(cm.pgBackups, page)
+}
+
+/*
+Function to get the CM managing a Page, the fixed distributed manager hashes the Page number onto a Node
+*/
+func (node *Node) managerFor(page int) *CentralManager {
+	if node.ring != nil {
+		return node.ring.managerFor(page)
+	}
+	if len(node.managers) == 0 {
+		return node.cm
+	}
+	return node.managers[page%len(node.managers)+1]
+}
+
+/*
+Function to Check if the Node runs without a CM, in which case the Owner of a Page keeps its Copy Set
+*/
+func (node *Node) managerless() bool {
+	return node.mode == DYNAMIC_DISTRIBUTED || node.mode == BROADCAST
+}
+
+/*
+Function to Check if the Owner of a Page keeps its Copy Set, either because there is no CM or under the Improved Centralized Manager
+*/
+func (node *Node) keepsCopySet() bool {
+	return node.managerless() || node.mode == IMPROVED_CENTRALIZED
+}
+
+/*
+Function to Check if the CM managing a Page keeps Backups of it, then even the Owner's writes have to go through the CM
+so the Backups get the new Content
+*/
+func (node *Node) keepsBackups(page int) bool {
+	return !node.keepsCopySet() && node.managerFor(page).replicationFactor > 1
+}
+
+/*
+Function to add a reader to the Copy Set the Owner keeps for a Page
+*/
+func (node *Node) addCopy(page int, nodeId int) {
+	if !inArray(nodeId, node.pgCopySet[page]) {
+		node.pgCopySet[page] = append(node.pgCopySet[page], nodeId)
+	}
+}
+
+/*
+Function to get the Probable Owner of a Page in the Dynamic Distributed Manager, initially the Node the Page hashes to
+*/
+func (node *Node) probOwnerOf(page int) int {
+	if owner, exists := node.probOwner[page]; exists {
+		return owner
+	}
+	return page%(len(node.nodes)+1) + 1
+}
+
+/*
+Function to send messages at Node, a reciever id of 0 means the CM managing the Page
+*/
+func (node *Node) sendMessage(msg Message, recieverId int) {
+	msg.seq = node.countMessage(msg, recieverId)
+	networkDelay := rand.Intn(50)
+	time.Sleep(time.Millisecond * time.Duration(networkDelay))
+	if inbox := node.inbox(msg, recieverId); inbox != nil {
+		inbox <- msg
+	}
+	delivered(msg.seq)
+}
+
+/*
+Function to send a Message without waiting for it, in its own goroutine or through the Node's Outbox
+*/
+func (node *Node) send(msg Message, recieverId int) {
+	if node.outbox == nil {
+		go node.sendMessage(msg, recieverId)
+		return
+	}
+	msg.seq = node.countMessage(msg, recieverId)
+	to := fmt.Sprintf("Node %d", recieverId)
+	if recieverId == 0 {
+		to = node.managerFor(msg.page).name()
+	}
+	node.outbox.post(to, node.inbox(msg, recieverId), msg)
+}
+
+/*
+Function to count and log a Message sent by a Node
+*/
+func (node *Node) countMessage(msg Message, recieverId int) int64 {
+	atomic.AddInt64(&messagesSent, 1)
+	atomic.AddInt64(&contentBytesSent, int64(len(msg.content)))
+	from := fmt.Sprintf("Node %d", node.id)
+	if recieverId != 0 {
+		fmt.Printf("> [Node %d] Sending Message of type %s to Node %d\n", node.id, msg.msgType, recieverId)
+		return track(msg, from, fmt.Sprintf("Node %d", recieverId))
+	}
+	atomic.AddInt64(&managerMessages, 1)
+	fmt.Printf("> [Node %d] Sending Message of type %s to %s\n", node.id, msg.msgType, node.managerFor(msg.page).name())
+	return track(msg, from, node.managerFor(msg.page).name())
+}
+
+/*
+Function to get the channel a Message from a Node goes to, 0 stands for the CM managing the Page
+*/
+func (node *Node) inbox(msg Message, recieverId int) chan Message {
+	if recieverId == 0 {
+		manager := node.managerFor(msg.page)
+		if msg.msgType == READREQ || msg.msgType == WRITEREQ || msg.msgType == DIFFREQ || msg.msgType == APPLYREQ || msg.msgType == UPGRADEREQ || msg.msgType == LOCKREQ || msg.msgType == TRYLOCKREQ || msg.msgType == UNLOCKREQ || msg.msgType == BINDREQ || msg.msgType == BARRIERREQ || msg.msgType == CONDWAITREQ || msg.msgType == CONDSIGNALREQ || msg.msgType == CONDBROADCASTREQ || msg.msgType == LOCKRENEWREQ {
+			return manager.msgReq
+		} else if msg.msgType == INVALIDATEACK || msg.msgType == READACK || msg.msgType == WRITEACK || msg.msgType == REPLICATEACK || msg.msgType == UPDATEACK || msg.msgType == DIFFACK || msg.msgType == SHIPACK {
+			return manager.msgRes
+		}
+		return nil
+	} else if msg.msgType == READFWD || msg.msgType == WRITEFWD || msg.msgType == INVALIDATE {
+		return node.peer(recieverId).msgReq
+	}
+	return node.peer(recieverId).msgRes
+}
+
+/*
+Function to get another Node by id, a Node that is both the Owner and the Requester of a Page sends to itself
+*/
+func (node *Node) peer(id int) *Node {
+	if id == node.id {
+		return node
+	}
+	return node.nodes[id]
+}
+
+/*
+Function to send a Read or Write Request when there is no CM, either to the Probable Owner or to every Node
+*/
+func (node *Node) sendRequest(msg Message) {
+	if node.mode == BROADCAST {
+		node.requests++
+		msg.request = node.requests
+		node.broadcasting = &msg
+		node.broadcast(msg)
+		return
+	}
+	node.send(msg, node.probOwnerOf(msg.page))
+}
+
+/*
+Function to send a Request to every other Node
+*/
+func (node *Node) broadcast(msg Message) {
+	for nodeid := range node.nodes {
+		node.send(msg, nodeid)
+	}
+}
+
+/*
+Function to Check if the Owner already answered a broadcast Request, else it is noted as answered. Every Node drops a broadcast
+that reaches it while the Page is moving between Owners, so the Requester broadcasts it again and the first one can still arrive
+*/
+func (node *Node) answered(msg Message) bool {
+	if msg.request == 0 {
+		return false
+	}
+	served := node.pgServed[msg.page]
+	if served[msg.requesterId] >= msg.request {
+		fmt.Printf("> [Node %d] Already answered Request %d of Node %d for Page %d\n", node.id, msg.request, msg.requesterId, msg.page)
+		return true
+	}
+	if served == nil {
+		served = make(map[int]int)
+		node.pgServed[msg.page] = served
+	}
+	served[msg.requesterId] = msg.request
+	return false
+}
+
+/*
+Function to forward a Request along the Probable Owner chain, a forwarded Write Request compresses the chain towards the Requester
+*/
+func (node *Node) forwardRequest(msg Message) {
+	if node.mode == BROADCAST {
+		// Every Node got the broadcast, only the Owner answers it
+		return
+	}
+
+	nextId := node.probOwnerOf(msg.page)
+	if msg.msgType == WRITEFWD && msg.requesterId != node.id {
+		node.probOwner[msg.page] = msg.requesterId
+	}
+
+	msg.senderId = node.id
+	msg.hops++
+	node.send(msg, nextId)
+}
+
+/*
+Function to record how many Nodes a Request visited before reaching the Owner
+*/
+func (node *Node) recordChainLength(msg Message) {
+	node.chainLengths = append(node.chainLengths, msg.hops)
+}
+
+/*
+Function to handle Read Forward Msgs at Node
+*/
+func (node *Node) handleReadFwd(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+
+	if node.managerless() {
+		if node.probOwnerOf(page) != node.id {
+			node.forwardRequest(msg)
+			return
+		}
+		if node.answered(msg) {
+			return
+		}
+		if _, exists := node.pgAccess[page]; !exists {
+			responseMsg := createMessage(READOWNERNIL, node.id, requesterId, page, "")
+			responseMsg.hops = msg.hops
+			node.send(*responseMsg, requesterId)
+			return
+		}
+	}
+	if node.keepsCopySet() {
+		node.addCopy(page, requesterId)
+		for prefetchPage := range msg.pages {
+			node.addCopy(prefetchPage, requesterId)
+		}
+	}
+
 	fmt.Printf("> [Node %d] Current AccessType: %s for Page %d\n", node.id, node.pgAccess[page], page)
 	if node.pgAccess[page] == READWRITE {
 		node.pgAccess[page] = READONLY
 	}
 
-	responseMsg := createMessage(READPG, node.id, requesterId, page, node.pgContent[page])
-	go node.sendMessage(*responseMsg, requesterId)
+	responseMsg := createMessage(READPG, node.id, requesterId, page, node.pgContent[page])
+	responseMsg.hops = msg.hops
+	if msg.pages != nil {
+		responseMsg.pages = make(map[int]string)
+		for prefetchPage := range msg.pages {
+			if node.pgAccess[prefetchPage] == READWRITE {
+				node.pgAccess[prefetchPage] = READONLY
+			}
+			responseMsg.pages[prefetchPage] = node.pgContent[prefetchPage]
+		}
+	}
+	node.send(*responseMsg, requesterId)
+}
+
+/*
+Function to handle Write Forward Msgs at Node
+*/
+func (node *Node) handleWriteFwd(msg Message) {
+	page := msg.page
+	requesterId := msg.requesterId
+
+	if node.managerless() {
+		if node.probOwnerOf(page) != node.id {
+			node.forwardRequest(msg)
+			return
+		}
+		if node.answered(msg) {
+			return
+		}
+		node.probOwner[page] = requesterId
+		if _, exists := node.pgAccess[page]; !exists {
+			responseMsg := createMessage(WRITEOWNERNIL, node.id, requesterId, page, "")
+			responseMsg.hops = msg.hops
+			responseMsg.served = node.pgServed[page]
+			delete(node.pgServed, page)
+			node.send(*responseMsg, requesterId)
+			return
+		}
+	}
+
+	responseMsg := createMessage(WRITEPG, node.id, requesterId, page, node.pgContent[page])
+	if msg.blind {
+		// The Requester replaces the whole Page, the Old Content would be thrown away
+		responseMsg.content = ""
+		responseMsg.blind = true
+	}
+	responseMsg.hops = msg.hops
+	responseMsg.count = msg.count
+	responseMsg.unwritten = node.unwrittenGrant(page)
+	responseMsg.copySet = node.pgCopySet[page]
+	responseMsg.served = node.pgServed[page]
+	delete(node.pgAccess, page)
+	delete(node.pgCopySet, page)
+	delete(node.pgServed, page)
+	//delete(node.pgContent, page)
+	node.send(*responseMsg, requesterId)
+}
+
+/*
+Function to handle Invalidate Msgs at Node
+*/
+func (node *Node) handleInvalidate(msg Message) {
+	page := msg.page
+	delete(node.pgAccess, page)
+	delete(node.pgLeases, page)
+	delete(node.prefetched, page)
+	delete(node.writerCopies, page)
+	//delete(node.pgContent, page)
+
+	responseMsg := createMessage(INVALIDATEACK, node.id, msg.requesterId, page, "")
+	responseMsg.unwritten = node.unwrittenGrant(page)
+	if node.keepsCopySet() {
+		// The writer invalidates the Copy Set itself and waits for the acks
+		if node.managerless() {
+			node.probOwner[page] = msg.requesterId
+		}
+		node.send(*responseMsg, msg.requesterId)
+		return
+	}
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to Invalidate every Copy in a Copy Set from the Node about to write, used when there is no CM
+*/
+func (node *Node) invalidateCopies(page int, copySet []int) {
+	invalidationMsg := createMessage(INVALIDATE, node.id, node.id, page, "")
+	invalidationMsgCount := 0
+	for _, nodeid := range copySet {
+		if nodeid != node.id {
+			node.send(*invalidationMsg, nodeid)
+			invalidationMsgCount++
+		}
+	}
+
+	for i := 0; i < invalidationMsgCount; i++ {
+		msg := node.awaitReply()
+		fmt.Printf("> [Node %d] Recieved Message of type %s from Node %d\n", node.id, msg.msgType, msg.senderId)
+	}
+}
+
+/*
+Function to handle Update Msgs at Node, installing the new Content of a Page another Node wrote
+*/
+func (node *Node) handleUpdate(msg Message) {
+	page := msg.page
+	node.pgAccess[page] = READONLY
+	node.pgContent[page] = msg.content
+	fmt.Printf("> [Node %d] Updated Page %d\n Content: %s\n", node.id, page, msg.content)
+
+	responseMsg := createMessage(UPDATEACK, node.id, msg.requesterId, page, "")
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Write Updated Msgs at Node, the write was pushed to every Copy holder and the writer keeps a READONLY Copy
+*/
+func (node *Node) handleWriteUpdated(msg Message) {
+	page := msg.page
+	node.pgAccess[page] = READONLY
+	node.pgContent[page] = msg.content
+	fmt.Printf("> [Node %d] Writing to Page %d\n Content: %s\n", node.id, page, msg.content)
+
+	responseMsg := createMessage(WRITEACK, node.id, msg.requesterId, page, "")
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Diff Forward Msgs at the Owner, merging another writer's Diff into the Page (and into the Owner's own Twin
+so the merged bytes are not sent back as its own changes), the Owner only gets READWRITE when no writer kept a Copy
+*/
+func (node *Node) handleDiffFwd(msg Message) {
+	page := msg.page
+	node.pgContent[page] = applyDiff(node.pgContent[page], msg.diff)
+	if twin, exists := node.pgTwin[page]; exists {
+		node.pgTwin[page] = applyDiff(twin, msg.diff)
+	}
+	node.pgAccess[page] = READONLY
+	if len(msg.copySet) == 0 {
+		node.pgAccess[page] = READWRITE
+	}
+	delete(node.pgLeases, page)
+	fmt.Printf("> [Node %d] Merged Diff from Node %d into Page %d\n Content: %s\n", node.id, msg.requesterId, page, node.pgContent[page])
+
+	responseMsg := createMessage(DIFFACK, node.id, msg.requesterId, page, node.pgContent[page])
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Ship Forward Msgs at the Owner, the Operation runs on the Page here and only the result travels back,
+all other Copies are gone so the Owner keeps the Page READWRITE
+*/
+func (node *Node) handleShipFwd(msg Message) {
+	page := msg.page
+	content, result := operations[msg.op](node.pgContent[page], msg.arg)
+	node.pgContent[page] = content
+	node.pgAccess[page] = READWRITE
+	fmt.Printf("> [Node %d] Applied Operation %s for Node %d to Page %d\n Content: %s\n", node.id, msg.op, msg.requesterId, page, content)
+
+	responseMsg := createMessage(SHIPACK, node.id, msg.requesterId, page, "")
+	if msg.count > 1 {
+		responseMsg.content = content
+	}
+	responseMsg.result = result
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Replicate Msgs at Node, storing a Backup of the Page Content
+*/
+func (node *Node) handleReplicate(msg Message) {
+	page := msg.page
+	node.pgReplica[page] = msg.content
+
+	responseMsg := createMessage(REPLICATEACK, node.id, msg.requesterId, page, "")
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Content Request Msgs at the Owner, the CM re-replicates the Page from it after a Backup holder died
+*/
+func (node *Node) handleContentReq(msg Message) {
+	page := msg.page
+	responseMsg := createMessage(REPLICATEACK, node.id, node.id, page, node.pgContent[page])
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Promote Msgs at Node, taking over Ownership of a Page from its Backup if not already the Owner
+*/
+func (node *Node) handlePromote(msg Message) {
+	page := msg.page
+	if node.pgAccess[page] != READWRITE {
+		node.pgContent[page] = node.pgReplica[page]
+		node.pgAccess[page] = READWRITE
+		delete(node.pgReplica, page)
+		delete(node.pgLeases, page)
+		fmt.Printf("> [Node %d] Promoted to Owner of Page %d\n Content: %s\n", node.id, page, node.pgContent[page])
+	}
+
+	responseMsg := createMessage(WRITEACK, node.id, node.id, page, node.pgContent[page])
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Read Owner Nil Msgs at Node
+*/
+func (node *Node) handleReadOwnerNil(msg Message) {
+	page := msg.page
+	fmt.Printf("> [Node %d] Recieved Message of type %s for Page %d\n", node.id, msg.msgType, page)
+	if node.managerless() {
+		node.probOwner[page] = msg.senderId
+		node.recordChainLength(msg)
+		node.finish()
+		return
+	}
+	responseMsg := createMessage(READACK, node.id, msg.requesterId, page, "")
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Write Owner Nil Msgs at Node
+*/
+func (node *Node) handleWriteOwnerNil(msg Message) {
+	page := msg.page
+	fmt.Printf("> [Node %d] Recieved Message of type %s for Page %d\n", node.id, msg.msgType, page)
+
+	node.writeToPg = node.pendingWrite(page, "")
+	node.pgContent[page] = node.writeToPg
+	node.pgAccess[page] = READWRITE
+	delete(node.pgLeases, page)
+
+	if node.managerless() {
+		fmt.Printf("> [Node %d] Writing to Page %d\n Content:%s\n", node.id, page, node.writeToPg)
+		node.probOwner[page] = node.id
+		node.pgServed[page] = msg.served
+		node.recordChainLength(msg)
+		node.finish()
+		return
+	}
+	responseMsg := createMessage(WRITEACK, node.id, msg.requesterId, page, "")
+	if msg.count > 1 {
+		// The Content is only needed back when the CM keeps Backups of it
+		responseMsg.content = node.writeToPg
+	}
+	fmt.Printf("> [Node %d] Writing to Page %d\n Content:%s\n", node.id, page, node.writeToPg)
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Read Page Msgs at Node
+*/
+func (node *Node) handleReadPg(msg Message) {
+	page := msg.page
+	content := msg.content
+
+	node.pgAccess[page] = READONLY
+	node.pgContent[page] = content
+
+	fmt.Printf("> [Node %d] Recieved Page %d Content from Owner for Reading\n Content: %s\n", node.id, page, content)
+	for prefetchPage, prefetchContent := range msg.pages {
+		if _, written := node.pgTwin[prefetchPage]; written {
+			// The local writes would be overwritten, the Page is fetched again when it is next read
+			continue
+		}
+		node.pgAccess[prefetchPage] = READONLY
+		node.pgContent[prefetchPage] = prefetchContent
+		node.prefetched[prefetchPage] = true
+		node.prefetchCount++
+		fmt.Printf("> [Node %d] Prefetched Page %d Content: %s\n", node.id, prefetchPage, prefetchContent)
+	}
+	if node.managerless() {
+		node.probOwner[page] = msg.senderId
+		node.recordChainLength(msg)
+		node.finish()
+		return
+	}
+	responseMsg := createMessage(READACK, node.id, msg.requesterId, page, "")
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to handle Write Page Msgs at Node
+*/
+func (node *Node) handleWritePg(msg Message) {
+	page := msg.page
+	content := msg.content
+
+	if msg.blind {
+		fmt.Printf("> [Node %d] Recieved Ownership of Page %d from Owner without its Old Content\n", node.id, page)
+	} else {
+		fmt.Printf("> [Node %d] Recieved Old Page %d Content from Owner for Writing\n Content: %s\n", node.id, page, content)
+	}
+	node.writeToPg = node.pendingWrite(page, content)
+	if node.keepsCopySet() {
+		node.invalidateCopies(page, msg.copySet)
+	}
+	if node.managerless() {
+		node.probOwner[page] = node.id
+		node.pgServed[page] = msg.served
+		node.recordChainLength(msg)
+	}
+	node.pgAccess[page] = READWRITE
+	node.pgContent[page] = node.writeToPg
+	delete(node.pgLeases, page)
+	fmt.Printf("> [Node %d] Writing to Page %d\n Content: %s\n", node.id, page, node.writeToPg)
+
+	if node.managerless() {
+		node.finish()
+		return
+	}
+	responseMsg := createMessage(WRITEACK, node.id, msg.requesterId, page, "")
+	if msg.count > 1 {
+		responseMsg.content = node.writeToPg
+	}
+	responseMsg.unwritten = msg.unwritten
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to Check if a write replaces the whole Page, only then the Old Content is not needed from the Owner
+*/
+func (node *Node) overwrites(page int) bool {
+	_, diffPending := node.pgDiffs[page]
+	_, updatePending := node.pgUpdates[page]
+	return node.blindWrites && !diffPending && !updatePending
+}
+
+/*
+Function to work out what a Node writes once it has the current Content of a Page: released writes only carry the bytes
+this Node changed and are merged into it, an atomic Update is applied to it, otherwise the Node writes what it asked for
+*/
+func (node *Node) pendingWrite(page int, current string) string {
+	if diff, pending := node.pgDiffs[page]; pending {
+		delete(node.pgDiffs, page)
+		return applyDiff(current, diff)
+	}
+	if update, pending := node.pgUpdates[page]; pending {
+		delete(node.pgUpdates, page)
+		return update(current)
+	}
+	return node.writeToPg
+}
+
+/*
+Function to handle Incoming Msgs at Node
+*/
+func (node *Node) handleIncomingMessage() {
+	mailbox := []Message{}
+	queued := []LocalOp{}
+	running := false
+	finished := make(chan int)
+	for {
+		if !running && len(queued) > 0 {
+			node.startOp(queued[0], finished)
+			queued = queued[1:]
+			running = true
+		}
+		// Responses wait in the mailbox until the Local Operation asks for them, so their sender never blocks
+		var replies chan Message
+		var reply Message
+		if len(mailbox) > 0 {
+			replies = node.replies
+			reply = mailbox[0]
+		}
+
+		select {
+		case msg := <-node.msgReq:
+			node.stateMu.Lock()
+			node.handleRequest(msg)
+			node.stateMu.Unlock()
+		case msg := <-node.msgRes:
+			mailbox = append(mailbox, msg)
+		case replies <- reply:
+			mailbox = mailbox[1:]
+		case op := <-node.ops:
+			queued = append(queued, op)
+		case <-finished:
+			running = false
+		case <-node.killChan:
+			fmt.Printf("> [Node %d] has died\n", node.id)
+			node.stateMu.Lock()
+			node.pgAccess = make(map[int]Permission)
+			node.pgContent = make(map[int]string)
+			node.pgReplica = make(map[int]string)
+			for name := range node.lockRenewals {
+				node.stopRenewing(name)
+			}
+			node.stateMu.Unlock()
+			return
+		}
+	}
+}
+
+/*
+Function to handle an Incoming Request at Node, it only sends Messages and never waits for one
+*/
+func (node *Node) handleRequest(msg Message) {
+	if msg.senderId == 0 {
+		fmt.Printf("> [Node %d] Recieved Message of type %s from %s\n", node.id, msg.msgType, node.managerFor(msg.page).name())
+	} else {
+		fmt.Printf("> [Node %d] Recieved Message of type %s from Node %d\n", node.id, msg.msgType, msg.senderId)
+	}
+	// An atomic Update running on this Node finishes before its Page can be taken away
+	node.rmwMu.Lock()
+	defer node.rmwMu.Unlock()
+	switch msg.msgType {
+	case READFWD:
+		node.handleReadFwd(msg)
+	case WRITEFWD:
+		node.handleWriteFwd(msg)
+	case INVALIDATE:
+		node.handleInvalidate(msg)
+	case REPLICATE:
+		node.handleReplicate(msg)
+	case PROMOTE:
+		node.handlePromote(msg)
+	case CONTENTREQ:
+		node.handleContentReq(msg)
+	case UPDATE:
+		node.handleUpdate(msg)
+	case DIFFFWD:
+		node.handleDiffFwd(msg)
+	case SHIPFWD:
+		node.handleShipFwd(msg)
+	}
+}
+
+/*
+Function to start a Local Operation, it holds the Node's state except while it waits for a reply
+so Incoming Requests are still handled in between, the next Operation starts once this one is complete
+*/
+func (node *Node) startOp(op LocalOp, finished chan int) {
+	go func() {
+		node.opMu.Lock()
+		node.current = op.handle
+		node.opMu.Unlock()
+
+		node.stateMu.Lock()
+		node.inOp = true
+		result := op.run()
+		node.inOp = false
+		node.stateMu.Unlock()
+		op.handle.returned(result)
+		<-op.handle.done
+		fmt.Printf("> [Node %d] Operation %d is complete\n", node.id, op.handle.id)
+
+		node.opMu.Lock()
+		node.current = nil
+		node.opMu.Unlock()
+		finished <- 1
+	}()
+}
+
+/*
+Function to submit a Local Operation to the Node's dispatcher and get its completion handle without waiting,
+Operations submitted from several goroutines run one after the other so each reply goes to the Operation that asked for it
+*/
+func (node *Node) Submit(run func() string) *OpHandle {
+	handle := &OpHandle{id: atomic.AddInt64(&opIds, 1), done: make(chan int)}
+	node.ops <- LocalOp{run: run, handle: handle}
+	return handle
+}
+
+/*
+Function to run a Local Operation through the Node's dispatcher and wait until it is complete
+*/
+func (node *Node) Run(run func()) {
+	node.Submit(func() string {
+		run()
+		return ""
+	}).Wait()
+}
+
+/*
+Function to start a Read of a Page, the handle's result is the Content read
+*/
+func (node *Node) ReadAsync(page int) *OpHandle {
+	return node.Submit(func() string {
+		node.executeRead(page)
+		return node.pgContent[page]
+	})
+}
+
+/*
+Function to start a Write of a Page, the handle's result is the Content written
+*/
+func (node *Node) WriteAsync(page int, content string) *OpHandle {
+	return node.Submit(func() string {
+		node.executeWrite(page, content)
+		return content
+	})
+}
+
+/*
+Function to wait until an Operation is complete and get its result
+*/
+func (handle *OpHandle) Wait() string {
+	<-handle.done
+	return handle.result
+}
+
+/*
+Function to count a protocol step an Operation started, it has to finish before the Operation is complete
+*/
+func (handle *OpHandle) add() {
+	handle.mu.Lock()
+	handle.pending++
+	handle.mu.Unlock()
+}
+
+/*
+Function to mark a protocol step of an Operation as finished
+*/
+func (handle *OpHandle) complete() {
+	handle.mu.Lock()
+	handle.pending--
+	handle.resolve()
+	handle.mu.Unlock()
+}
+
+/*
+Function to mark that an Operation returned, steps it started may still be in progress at the CM
+*/
+func (handle *OpHandle) returned(result string) {
+	handle.mu.Lock()
+	handle.ran = true
+	handle.result = result
+	handle.resolve()
+	handle.mu.Unlock()
+}
+
+/*
+Function to close the handle once the Operation returned and none of its steps is pending, the caller holds mu
+*/
+func (handle *OpHandle) resolve() {
+	if handle.ran && handle.pending <= 0 && !handle.resolved {
+		handle.resolved = true
+		close(handle.done)
+	}
+}
+
+/*
+Function to start a protocol step at Node, counted on the running Operation
+*/
+func (node *Node) begin() {
+	node.opMu.Lock()
+	if node.current != nil {
+		node.current.add()
+	}
+	node.opMu.Unlock()
+}
+
+/*
+Function to finish a protocol step at Node without the CM
+*/
+func (node *Node) finish() {
+	markProgress()
+	node.resolve()
+}
+
+/*
+Function to mark a protocol step of the running Operation as finished
+*/
+func (node *Node) resolve() {
+	node.opMu.Lock()
+	if node.current != nil {
+		node.current.complete()
+	}
+	node.opMu.Unlock()
+}
+
+/*
+Function to wait for the next reply to this Node, Incoming Requests are handled while the Local Operation waits,
+waiting anywhere else would race with the dispatcher so it is refused
+*/
+func (node *Node) awaitReply() Message {
+	if !node.inOp {
+		panic(fmt.Sprintf("Node %d waited for a reply outside a Local Operation, use node.Run or node.Submit", node.id))
+	}
+	atomic.StoreInt32(&node.waiting, 1)
+	defer atomic.StoreInt32(&node.waiting, 0)
+	node.stateMu.Unlock()
+	defer node.stateMu.Lock()
+	if node.broadcasting == nil {
+		return <-node.replies
+	}
+
+	request := *node.broadcasting
+	node.broadcasting = nil
+	for {
+		select {
+		case msg := <-node.replies:
+			return msg
+		case <-time.After(BROADCAST_RETRY):
+			// Every Node may have dropped it while the Page was on its way to a new Owner
+			fmt.Printf("> [Node %d] No Owner answered the %s for Page %d, broadcasting it again\n", node.id, request.msgType, request.page)
+			node.broadcast(request)
+		}
+	}
+}
+
+/*
+Function to perform a Read End to End at Node
+*/
+func (node *Node) executeRead(page int) {
+	node.begin()
+	if expiry, leased := node.pgLeases[page]; leased && node.pgAccess[page] == READONLY && time.Now().After(expiry) {
+		fmt.Printf("> [Node %d] Lease on Page %d ran out, dropping the Copy\n", node.id, page)
+		delete(node.pgAccess, page)
+		delete(node.pgLeases, page)
+	}
+	node.dropWriterCopy(page)
+	if _, exists := node.pgAccess[page]; exists {
+		content := node.pgContent[page]
+		if node.prefetched[page] {
+			node.prefetchHits++
+			delete(node.prefetched, page)
+		}
+		fmt.Printf("> [Node %d] Reading Cached Page %d Content: %s\n", node.id, page, content)
+		node.finish()
+		return
+	}
+
+	if node.managerless() {
+		if node.probOwnerOf(page) == node.id {
+			fmt.Printf("> [Node %d] Owns Page %d which has no Content yet\n", node.id, page)
+			node.finish()
+			return
+		}
+		readFwdMsg := createMessage(READFWD, node.id, node.id, page, "")
+		readFwdMsg.hops = 1
+		node.sendRequest(*readFwdMsg)
+	} else {
+		readReqMsg := createMessage(READREQ, node.id, node.id, page, "")
+		readReqMsg.count = node.prefetch
+		// A writer's Copy is kept when the other writers release their Diffs
+		readReqMsg.writer = node.consistency == MULTIPLE_WRITER && node.inInterval
+		node.send(*readReqMsg, 0)
+	}
+	// Counted from before the request, so the Copy expires here no later than the CM lets it expire
+	requested := time.Now()
+	// Writes made since the Acquire are not in the Page that comes back, they are put on top of it again
+	twin, written := node.pgTwin[page]
+	local := computeDiff(twin, node.pgContent[page])
+
+	msg := node.awaitReply()
+	switch msg.msgType {
+	case READOWNERNIL:
+		node.handleReadOwnerNil(msg)
+	case READPG:
+		node.handleReadPg(msg)
+		node.writerCopies[page] = !node.managerless() && node.consistency == MULTIPLE_WRITER && node.inInterval
+		if written {
+			node.rebaseTwin(page, twin, local)
+		}
+		if node.readLease > 0 && !node.managerless() {
+			node.pgLeases[page] = requested.Add(node.readLease)
+			for prefetchPage := range msg.pages {
+				node.pgLeases[prefetchPage] = requested.Add(node.readLease)
+			}
+		}
+	case WRITEPG:
+		node.handleMigratoryPg(msg)
+		if written {
+			node.rebaseTwin(page, twin, local)
+		}
+	}
+}
+
+/*
+Function to put the writes of the current Acquire/Release interval back on a Page fetched again after it was invalidated,
+the fetched Page becomes the Twin so Release only sends these writes
+*/
+func (node *Node) rebaseTwin(page int, twin string, local PageDiff) {
+	fetched := node.pgContent[page]
+	if local.length >= len(twin) && len(fetched) > local.length {
+		local.length = len(fetched)
+	}
+	node.pgTwin[page] = fetched
+	node.pgContent[page] = applyDiff(fetched, local)
+	fmt.Printf("> [Node %d] Kept the local writes on the fetched Page %d\n Content: %s\n", node.id, page, node.pgContent[page])
+}
+
+/*
+Function to handle a Write Page Msg that answers a Read of a migratory Page, the Node becomes the Owner so its next write stays local
+*/
+func (node *Node) handleMigratoryPg(msg Message) {
+	page := msg.page
+
+	fmt.Printf("> [Node %d] Recieved migratory Page %d with READWRITE access on a Read\n Content: %s\n", node.id, page, msg.content)
+	node.pgAccess[page] = READWRITE
+	node.pgContent[page] = msg.content
+	node.migrated[page] = msg.content
+	delete(node.pgLeases, page)
+
+	responseMsg := createMessage(WRITEACK, node.id, msg.requesterId, page, "")
+	if msg.count > 1 {
+		responseMsg.content = msg.content
+	}
+	responseMsg.unwritten = msg.unwritten
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to Check if the Node gives up a Page it got READWRITE on a Read without writing it in the meantime
+*/
+func (node *Node) unwrittenGrant(page int) bool {
+	granted, exists := node.migrated[page]
+	delete(node.migrated, page)
+	return exists && granted == node.pgContent[page]
+}
+
+/*
+Function to perform a write End to End at Node
+*/
+func (node *Node) executeWrite(page int, content string) {
+	if node.consistency != SEQUENTIAL && node.inInterval {
+		node.writeLocally(page, content)
+		return
+	}
+
+	node.begin()
+	if accessType, exists := node.pgAccess[page]; exists {
+		if accessType == READWRITE && node.pgContent[page] == content {
+			fmt.Printf("> [Node %d] Content is same as what is trying to be written for Page %d\n", node.id, page)
+			node.finish()
+			return
+		} else if accessType == READWRITE && !node.keepsBackups(page) {
+			node.writeToPg = content
+			node.pgAccess[page] = READWRITE
+			node.pgContent[page] = node.writeToPg
+			fmt.Printf("> [Node %d] Writing to Page %d\n Content: %s\n", node.id, page, node.writeToPg)
+
+			// The Owner holds the only Copy, the CM has nothing to do for this write
+			node.finish()
+			return
+		}
+	}
+
+	node.writeToPg = content
+	node.requestWrite(page)
+}
+
+/*
+Function to get write access to a Page from its Owner and write it, through the CM or without one
+*/
+func (node *Node) requestWrite(page int) {
+	if node.managerless() {
+		if node.probOwnerOf(page) == node.id {
+			node.invalidateCopies(page, node.pgCopySet[page])
+			delete(node.pgCopySet, page)
+			node.writeToPg = node.pendingWrite(page, node.pgContent[page])
+			node.pgAccess[page] = READWRITE
+			node.pgContent[page] = node.writeToPg
+			fmt.Printf("> [Node %d] Writing to Page %d\n Content: %s\n", node.id, page, node.writeToPg)
+			node.finish()
+			return
+		}
+		writeFwdMsg := createMessage(WRITEFWD, node.id, node.id, page, "")
+		writeFwdMsg.hops = 1
+		writeFwdMsg.blind = node.overwrites(page)
+		node.sendRequest(*writeFwdMsg)
+	} else {
+		node.dropWriterCopy(page)
+		writeReqMsg := createMessage(WRITEREQ, node.id, node.id, page, "")
+		_, writeReqMsg.rmw = node.pgUpdates[page]
+		if node.managerFor(page).policyFor(page) == WRITE_UPDATE && !writeReqMsg.rmw {
+			// Only a pushed update needs the new Content at the CM, any other write gets the Page from its Owner
+			writeReqMsg.content = node.writeToPg
+		}
+		accessType, exists := node.pgAccess[page]
+		if exists && (accessType == READWRITE || node.upgrade) && !node.keepsCopySet() {
+			// The Copy is current, only the permission has to change, or the Owner writes and the CM updates the Backups
+			writeReqMsg.msgType = UPGRADEREQ
+		}
+		writeReqMsg.blind = node.overwrites(page)
+		node.send(*writeReqMsg, 0)
+	}
+
+	msg := node.awaitReply()
+	switch msg.msgType {
+	case WRITEOWNERNIL:
+		node.handleWriteOwnerNil(msg)
+	case WRITEPG:
+		node.handleWritePg(msg)
+	case WRITEUPDATED:
+		node.handleWriteUpdated(msg)
+	case UPGRADEGRANT:
+		node.handleUpgradeGrant(msg)
+	}
+}
+
+/*
+Function to handle Upgrade Grant Msgs at Node, the Node writes on top of its own READONLY Copy
+*/
+func (node *Node) handleUpgradeGrant(msg Message) {
+	page := msg.page
+
+	fmt.Printf("> [Node %d] Upgraded Page %d to READWRITE without a Content transfer\n", node.id, page)
+	node.writeToPg = node.pendingWrite(page, node.pgContent[page])
+	node.pgAccess[page] = READWRITE
+	node.pgContent[page] = node.writeToPg
+	delete(node.pgLeases, page)
+	fmt.Printf("> [Node %d] Writing to Page %d\n Content: %s\n", node.id, page, node.writeToPg)
+
+	responseMsg := createMessage(WRITEACK, node.id, msg.requesterId, page, "")
+	if msg.count > 1 {
+		responseMsg.content = node.writeToPg
+	}
+	node.send(*responseMsg, 0)
+}
+
+/*
+Function to atomically replace the Content of a Page with update(Content) and return the Content it replaced,
+the update runs while this Node holds the Page READWRITE, so no other Node can read or write the Page in between
+*/
+func (node *Node) Update(page int, update func(string) string) string {
+	node.rmwMu.Lock()
+	if accessType, exists := node.pgAccess[page]; exists && accessType == READWRITE && !node.keepsBackups(page) {
+		old := node.pgContent[page]
+		node.pgContent[page] = update(old)
+		fmt.Printf("> [Node %d] Atomically updated Page %d\n Content: %s\n", node.id, page, node.pgContent[page])
+		node.rmwMu.Unlock()
+		return old
+	}
+	node.rmwMu.Unlock()
+
+	old := ""
+	node.pgUpdates[page] = func(content string) string {
+		old = content
+		return update(content)
+	}
+	node.begin()
+	node.requestWrite(page)
+	return old
+}
+
+/*
+Function to apply a registered Operation to a Page atomically and return its result, the CM either migrates the Page here
+or ships the Operation to the Owner when the Page is contended
+*/
+func (node *Node) Apply(page int, op string, arg string) string {
+	result := ""
+	update := func(content string) string {
+		newContent, opResult := operations[op](content, arg)
+		result = opResult
+		return newContent
+	}
+	if node.managerless() {
+		node.Update(page, update)
+		return result
+	}
+
+	node.rmwMu.Lock()
+	if accessType, exists := node.pgAccess[page]; exists && accessType == READWRITE && !node.keepsBackups(page) {
+		node.pgContent[page] = update(node.pgContent[page])
+		fmt.Printf("> [Node %d] Applied Operation %s to Page %d\n Content: %s\n", node.id, op, page, node.pgContent[page])
+		node.rmwMu.Unlock()
+		return result
+	}
+	node.rmwMu.Unlock()
+
+	node.pgUpdates[page] = update
+	node.begin()
+	applyReqMsg := createMessage(APPLYREQ, node.id, node.id, page, "")
+	applyReqMsg.op = op
+	applyReqMsg.arg = arg
+	node.send(*applyReqMsg, 0)
+
+	msg := node.awaitReply()
+	switch msg.msgType {
+	case WRITEOWNERNIL:
+		node.handleWriteOwnerNil(msg)
+	case WRITEPG:
+		node.handleWritePg(msg)
+	case APPLYRESULT:
+		delete(node.pgUpdates, page)
+		result = msg.result
+		fmt.Printf("> [Node %d] Recieved Message of type %s for Page %d\n Result: %s\n", node.id, msg.msgType, page, result)
+	}
+	return result
+}
+
+/*
+Function to atomically set the Content of a Page to new if it is old, returns whether it was swapped
+*/
+func (node *Node) CompareAndSwap(page int, old string, new string) bool {
+	previous := node.Update(page, func(content string) string {
+		if content == old {
+			return new
+		}
+		return content
+	})
+	return previous == old
+}
+
+/*
+Function to atomically add delta to a counter stored in a Page and return the value before the add
+*/
+func (node *Node) FetchAndAdd(page int, delta int) int {
+	previous := node.Update(page, func(content string) string {
+		count, _ := strconv.Atoi(content)
+		return strconv.Itoa(count + delta)
+	})
+	count, _ := strconv.Atoi(previous)
+	return count
+}
+
+/*
+Function to get the name of the Lock a Transaction takes on a Page
+*/
+func txLockName(page int) string {
+	return fmt.Sprintf("page-%d", page)
+}
+
+/*
+Function to begin a Transaction over a set of Pages at Node, the Lock of every Page is taken in ascending Page order
+so two Transactions can never wait on each other
+*/
+func (node *Node) Begin(pages []int) *Transaction {
+	sorted := []int{}
+	for _, page := range pages {
+		if !inArray(page, sorted) {
+			sorted = append(sorted, page)
+		}
+	}
+	sort.Ints(sorted)
+	fmt.Printf("> [Node %d] Beginning Transaction over Pages %d\n", node.id, sorted)
+	for _, page := range sorted {
+		node.Lock(txLockName(page))
+	}
+
+	tx := Transaction{
+		node:   node,
+		pages:  sorted,
+		writes: make(map[int]string),
+	}
+	return &tx
+}
+
+/*
+Function to read a Page in a Transaction, a Page written earlier in the Transaction reads as written
+*/
+func (tx *Transaction) Read(page int) string {
+	if content, written := tx.writes[page]; written {
+		return content
+	}
+	if !inArray(page, tx.pages) {
+		fmt.Printf("> [Node %d] Page %d is not part of the Transaction, it will abort\n", tx.node.id, page)
+		tx.aborted = true
+	}
+	tx.node.executeRead(page)
+	return tx.node.pgContent[page]
+}
+
+/*
+Function to write a Page in a Transaction, nothing is visible to other Nodes before Commit
+*/
+func (tx *Transaction) Write(page int, content string) {
+	if !inArray(page, tx.pages) {
+		fmt.Printf("> [Node %d] Page %d is not part of the Transaction, it will abort\n", tx.node.id, page)
+		tx.aborted = true
+		return
+	}
+	tx.writes[page] = content
+}
+
+/*
+Function to commit a Transaction, write ownership of every written Page is taken and all writes are applied at once
+while no other Node holds a Copy, returns false if the Transaction aborted instead
+*/
+func (tx *Transaction) Commit() bool {
+	if tx.aborted {
+		tx.Abort()
+		return false
+	}
+	node := tx.node
+	written := []int{}
+	for _, page := range tx.pages {
+		if _, exists := tx.writes[page]; exists {
+			written = append(written, page)
+		}
+	}
+
+	for {
+		for _, page := range written {
+			if accessType, exists := node.pgAccess[page]; !exists || accessType != READWRITE {
+				node.Update(page, func(content string) string { return content })
+			}
+		}
+
+		// A plain read may have taken a Copy in the meantime, then ownership is taken again
+		node.rmwMu.Lock()
+		owned := true
+		for _, page := range written {
+			if accessType, exists := node.pgAccess[page]; !exists || accessType != READWRITE {
+				owned = false
+			}
+		}
+		if owned {
+			for _, page := range written {
+				node.pgContent[page] = tx.writes[page]
+			}
+			fmt.Printf("> [Node %d] Committed Transaction writing Pages %d\n", node.id, written)
+			node.rmwMu.Unlock()
+			break
+		}
+		node.rmwMu.Unlock()
+	}
+	for _, page := range written {
+		if node.keepsBackups(page) {
+			// The Backups still hold the Content from before the Commit, the Locks keep other Transactions out meanwhile
+			node.Update(page, func(content string) string { return content })
+		}
+	}
+	tx.release()
+	return true
+}
+
+/*
+Function to abort a Transaction, none of its writes are applied
+*/
+func (tx *Transaction) Abort() {
+	fmt.Printf("> [Node %d] Aborted Transaction over Pages %d\n", tx.node.id, tx.pages)
+	tx.writes = make(map[int]string)
+	tx.release()
+}
+
+/*
+Function to release the Locks of a finished Transaction
+*/
+func (tx *Transaction) release() {
+	for i := len(tx.pages) - 1; i >= 0; i-- {
+		tx.node.Unlock(txLockName(tx.pages[i]))
+	}
+}
+
+/*
+Function to write a Page inside an Acquire/Release interval, a Twin of the Page is kept on the first write
+*/
+func (node *Node) writeLocally(page int, content string) {
+	if _, exists := node.pgAccess[page]; !exists {
+		node.executeRead(page)
+	}
+	if _, exists := node.pgTwin[page]; !exists {
+		node.pgTwin[page] = node.pgContent[page]
+	}
+	node.pgContent[page] = content
+	fmt.Printf("> [Node %d] Writing to Page %d locally until Release\n Content: %s\n", node.id, page, content)
+}
+
+/*
+Function to start an Acquire/Release interval at Node
+*/
+func (node *Node) Acquire() {
+	if node.consistency != RELEASE && node.consistency != MULTIPLE_WRITER {
+		return
+	}
+	fmt.Printf("> [Node %d] Acquire\n", node.id)
+	node.inInterval = true
+}
+
+/*
+Function to end an Acquire/Release interval at Node, the Diff of every Page written against its Twin is propagated eagerly
+through the usual Write path and merged into the Content the Owner sends back, or with multiple writers sent to the Owner
+*/
+func (node *Node) Release() {
+	if node.consistency != RELEASE && node.consistency != MULTIPLE_WRITER {
+		return
+	}
+	fmt.Printf("> [Node %d] Release\n", node.id)
+	node.inInterval = false
+
+	for page, twin := range node.pgTwin {
+		delete(node.pgTwin, page)
+		diff := computeDiff(twin, node.pgContent[page])
+		if len(diff.runs) == 0 && diff.length == len(twin) {
+			continue
+		}
+		if node.pgAccess[page] == READWRITE {
+			if !node.keepsBackups(page) {
+				// No other Node holds a Copy, the local Content is already the Page
+				continue
+			}
+			// The Backups still hold the Content from before the interval, so the write goes through the CM to reach them
+			node.begin()
+			node.writeToPg = node.pgContent[page]
+			node.requestWrite(page)
+			continue
+		}
+		if node.consistency == MULTIPLE_WRITER {
+			node.flushDiff(page, diff)
+			continue
+		}
+		node.pgDiffs[page] = diff
+		node.executeWrite(page, node.pgContent[page])
+	}
+}
+
+/*
+Function to send a released Diff to the CM to be merged at the Owner of the Page
+*/
+func (node *Node) flushDiff(page int, diff PageDiff) {
+	node.begin()
+	diffReqMsg := createMessage(DIFFREQ, node.id, node.id, page, "")
+	diffReqMsg.diff = diff
+	node.send(*diffReqMsg, 0)
+
+	msg := node.awaitReply()
+	fmt.Printf("> [Node %d] Recieved Message of type %s for Page %d\n", node.id, msg.msgType, page)
+	if msg.writer {
+		node.pgAccess[page] = READONLY
+		node.pgContent[page] = msg.content
+		node.writerCopies[page] = true
+	}
+}
+
+/*
+Function to drop a Copy a writer kept under multiple writers, it may miss the other writers' Diffs so it is only used
+inside an Acquire/Release interval
+*/
+func (node *Node) dropWriterCopy(page int) {
+	if node.writerCopies[page] && !node.inInterval {
+		fmt.Printf("> [Node %d] Copy of Page %d may miss other writers' Diffs, fetching it again\n", node.id, page)
+		delete(node.pgAccess, page)
+		delete(node.writerCopies, page)
+	}
+}
+
+/*
+Function to get the Page number a named Lock or Barrier is routed by, so it lives at the CM that would manage that Page,
+a Cond lives with the Lock it is used with
+*/
+func lockHome(name string) int {
+	return int(hashKey("lock-"+name)%1000000) + 1
+}
+
+/*
+Function to acquire a named Lock at Node, blocking until the CM grants it
+*/
+func (node *Node) Lock(name string) {
+	if node.managerless() {
+		fmt.Printf("> [Node %d] There is no CM to serve Lock %s in %s mode\n", node.id, name, node.mode)
+		return
+	}
+	node.begin()
+	lockReqMsg := createMessage(LOCKREQ, node.id, node.id, lockHome(name), "")
+	lockReqMsg.lock = name
+	node.send(*lockReqMsg, 0)
+
+	msg := node.awaitReply()
+	fmt.Printf("> [Node %d] Recieved Message of type %s for Lock %s\n", node.id, msg.msgType, name)
+	node.installLockPages(msg)
+	node.renewLease(name)
+}
+
+/*
+Function to try to acquire a named Lock at Node without waiting, returns whether it was granted
+*/
+func (node *Node) TryLock(name string) bool {
+	if node.managerless() {
+		fmt.Printf("> [Node %d] There is no CM to serve Lock %s in %s mode\n", node.id, name, node.mode)
+		return false
+	}
+	node.begin()
+	lockReqMsg := createMessage(TRYLOCKREQ, node.id, node.id, lockHome(name), "")
+	lockReqMsg.lock = name
+	node.send(*lockReqMsg, 0)
+
+	msg := node.awaitReply()
+	fmt.Printf("> [Node %d] Recieved Message of type %s for Lock %s\n", node.id, msg.msgType, name)
+	if msg.msgType != LOCKGRANT {
+		return false
+	}
+	node.installLockPages(msg)
+	node.renewLease(name)
+	return true
+}
+
+/*
+Function to keep renewing the Lease on a held Lock every quarter Lease, so the CM only takes the Lock from a Node that died
+*/
+func (node *Node) renewLease(name string) {
+	node.stopRenewing(name)
+	stop := make(chan int)
+	node.lockRenewals[name] = stop
+	go func() {
+		ticker := time.NewTicker(LOCK_LEASE / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				renewReqMsg := createMessage(LOCKRENEWREQ, node.id, node.id, lockHome(name), "")
+				renewReqMsg.lock = name
+				node.send(*renewReqMsg, 0)
+			}
+		}
+	}()
+}
+
+/*
+Function to stop renewing the Lease on a Lock the Node gives up
+*/
+func (node *Node) stopRenewing(name string) {
+	if stop, exists := node.lockRenewals[name]; exists {
+		close(stop)
+		delete(node.lockRenewals, name)
+	}
+}
+
+/*
+Function to release a named Lock at Node
+*/
+func (node *Node) Unlock(name string) {
+	if node.managerless() {
+		return
+	}
+	node.begin()
+	node.stopRenewing(name)
+	unlockReqMsg := createMessage(UNLOCKREQ, node.id, node.id, lockHome(name), "")
+	unlockReqMsg.lock = name
+	if pages, exists := node.lockPages[name]; exists {
+		unlockReqMsg.pages = make(map[int]string)
+		for _, page := range pages {
+			unlockReqMsg.pages[page] = node.pgContent[page]
+			delete(node.pgAccess, page)
+		}
+		delete(node.lockPages, name)
+	}
+	node.send(*unlockReqMsg, 0)
+}
+
+/*
+Function to wait at a named Barrier until n Nodes have arrived, writes made in a Release interval are released first
+so they are visible to every Node after the Barrier
+*/
+func (node *Node) Barrier(name string, n int) {
+	if node.managerless() {
+		fmt.Printf("> [Node %d] There is no CM to serve Barrier %s in %s mode\n", node.id, name, node.mode)
+		return
+	}
+	inInterval := node.inInterval
+	if inInterval {
+		node.Release()
+	}
+
+	node.begin()
+	barrierReqMsg := createMessage(BARRIERREQ, node.id, node.id, lockHome(name), "")
+	barrierReqMsg.barrier = name
+	barrierReqMsg.count = n
+	node.send(*barrierReqMsg, 0)
+
+	msg := node.awaitReply()
+	fmt.Printf("> [Node %d] Recieved Message of type %s for Barrier %s\n", node.id, msg.msgType, name)
+	if inInterval {
+		node.Acquire()
+	}
+}
+
+/*
+Function to wait on a named Cond while holding a Lock, the Lock is released while waiting and held again on return
+*/
+func (node *Node) Wait(cond string, lock string) {
+	if node.managerless() {
+		fmt.Printf("> [Node %d] There is no CM to serve Cond %s in %s mode\n", node.id, cond, node.mode)
+		return
+	}
+	node.begin()
+	node.stopRenewing(lock)
+	waitReqMsg := createMessage(CONDWAITREQ, node.id, node.id, lockHome(lock), "")
+	waitReqMsg.lock = lock
+	waitReqMsg.cond = cond
+	if pages, exists := node.lockPages[lock]; exists {
+		waitReqMsg.pages = make(map[int]string)
+		for _, page := range pages {
+			waitReqMsg.pages[page] = node.pgContent[page]
+			delete(node.pgAccess, page)
+		}
+		delete(node.lockPages, lock)
+	}
+	node.send(*waitReqMsg, 0)
+
+	msg := node.awaitReply()
+	fmt.Printf("> [Node %d] Recieved Message of type %s for Lock %s after waiting on Cond %s\n", node.id, msg.msgType, lock, cond)
+	node.installLockPages(msg)
+	node.renewLease(lock)
+}
+
+/*
+Function to wake the first Node waiting on a named Cond
+*/
+func (node *Node) Signal(cond string, lock string) {
+	node.signalCond(CONDSIGNALREQ, cond, lock)
+}
+
+/*
+Function to wake every Node waiting on a named Cond
+*/
+func (node *Node) Broadcast(cond string, lock string) {
+	node.signalCond(CONDBROADCASTREQ, cond, lock)
+}
+
+/*
+Function to send a Cond Signal or Broadcast Request to the CM serving the Lock of the Cond
+*/
+func (node *Node) signalCond(msgType MessageType, cond string, lock string) {
+	if node.managerless() {
+		return
+	}
+	node.begin()
+	signalReqMsg := createMessage(msgType, node.id, node.id, lockHome(lock), "")
+	signalReqMsg.lock = lock
+	signalReqMsg.cond = cond
+	node.send(*signalReqMsg, 0)
+}
+
+/*
+Function to install the Pages bound to a granted Lock under entry consistency, they are READWRITE at Node until Unlock
+so every access in between stays local
+*/
+func (node *Node) installLockPages(msg Message) {
+	if node.consistency != ENTRY || len(msg.pages) == 0 {
+		return
+	}
+	pages := []int{}
+	for page, content := range msg.pages {
+		node.pgContent[page] = content
+		node.pgAccess[page] = READWRITE
+		pages = append(pages, page)
+	}
+	sort.Ints(pages)
+	node.lockPages[msg.lock] = pages
+	fmt.Printf("> [Node %d] Brought Pages %d bound to Lock %s up to date\n", node.id, pages, msg.lock)
+}
+
+/*
+Function to bind Pages to a named Lock for entry consistency, starting from the Content this Node holds for them
+*/
+func (node *Node) BindPages(name string, pages []int) {
+	if node.managerless() {
+		fmt.Printf("> [Node %d] There is no CM to serve Lock %s in %s mode\n", node.id, name, node.mode)
+		return
+	}
+	node.begin()
+	bindReqMsg := createMessage(BINDREQ, node.id, node.id, lockHome(name), "")
+	bindReqMsg.lock = name
+	bindReqMsg.pages = make(map[int]string)
+	for _, page := range pages {
+		bindReqMsg.pages[page] = node.pgContent[page]
+	}
+	node.send(*bindReqMsg, 0)
+
+	msg := node.awaitReply()
+	fmt.Printf("> [Node %d] Recieved Message of type %s for Lock %s\n", node.id, msg.msgType, name)
+}
+
+/*
+Function to write some bytes of a Page at an offset, outside an Acquire/Release interval the bytes go into the Content
+the write gets so another Node's bytes are kept, inside one the Diff against the Twin keeps them at Release
+*/
+func (node *Node) writeBytes(page int, offset int, data string) {
+	patch := func(content string) string {
+		buf := []byte(content)
+		for len(buf) < offset+len(data) {
+			buf = append(buf, ' ')
+		}
+		copy(buf[offset:], data)
+		return string(buf)
+	}
+	if !node.inInterval {
+		node.Update(page, patch)
+		return
+	}
+	if _, exists := node.pgAccess[page]; !exists {
+		node.executeRead(page)
+	}
+	node.executeWrite(page, patch(node.pgContent[page]))
+}
+
+/*
+Function to Print the Pages a Node owns and the Probable Owner chain lengths its Requests followed
+*/
+func (node *Node) PrintState() {
+	fmt.Printf("**************************************************\n  NODE %d STATE  \n**************************************************\n", node.id)
+	for page, owner := range node.probOwner {
+		if owner == node.id {
+			fmt.Printf("> Page: %d, Owner: %d :: Access Type: %s , Copies: %d\n", page, owner, node.pgAccess[page], node.pgCopySet[page])
+		}
+	}
+
+	totalHops, maxHops := 0, 0
+	for _, hops := range node.chainLengths {
+		totalHops += hops
+		if hops > maxHops {
+			maxHops = hops
+		}
+	}
+	if len(node.chainLengths) > 0 {
+		fmt.Printf("> Chain Length over %d Requests :: Average: %.2f , Max: %d\n", len(node.chainLengths), float64(totalHops)/float64(len(node.chainLengths)), maxHops)
+	}
+	if node.prefetch > 0 || node.prefetchCount > 0 {
+		fmt.Printf("> Prefetched Pages: %d , Hits: %d , Hit Rate: %.2f\n", node.prefetchCount, node.prefetchHits, node.prefetchHitRate())
+	}
+}
+
+/*
+Function to get the share of Prefetched Pages that were read before being invalidated
+*/
+func (node *Node) prefetchHitRate() float64 {
+	if node.prefetchCount == 0 {
+		return 0
+	}
+	return float64(node.prefetchHits) / float64(node.prefetchCount)
+}
+
+/*
+Function to Construct the Central Managers and Nodes of a Cluster and start their Message Loops
+*/
+func newCluster(mode ManagerMode, totalNodes int) (map[int]*CentralManager, map[int]*Node) {
+	managers := make(map[int]*CentralManager)
+	ring := NewHashRing()
+	if mode == CENTRALIZED || mode == IMPROVED_CENTRALIZED {
+		managers[0] = NewCM(0)
+		managers[0].ownerCopySets = mode == IMPROVED_CENTRALIZED
+	} else if mode == FIXED_DISTRIBUTED {
+		for i := 1; i <= totalNodes; i++ {
+			managers[i] = NewCM(i)
+		}
+	} else if mode == SHARDED {
+		for i := 1; i <= SHARD_COUNT; i++ {
+			ring.place(NewCM(i))
+		}
+		managers = ring.shards
+	}
+
+	nodeMap := make(map[int]*Node)
+	for i := 1; i <= totalNodes; i++ {
+		var node *Node
+		if mode == CENTRALIZED || mode == IMPROVED_CENTRALIZED {
+			node = NewNode(i, managers[0])
+		} else if mode == FIXED_DISTRIBUTED {
+			node = NewNode(i, managers[i])
+			node.managers = managers
+		} else if mode == SHARDED {
+			node = NewNode(i, nil)
+			node.ring = ring
+		} else {
+			node = NewNode(i, nil)
+		}
+		node.mode = mode
+		nodeMap[i] = node
+	}
+
+	for _, cm := range managers {
+		cm.nodes = make(map[int]*Node)
+		for id, node := range nodeMap {
+			cm.nodes[id] = node
+		}
+		go cm.handleIncomingMessages()
+	}
+
+	for _, nodei := range nodeMap {
+		for _, nodej := range nodeMap {
+			if nodei.id != nodej.id {
+				nodei.nodes[nodej.id] = nodej
+			}
+		}
+	}
+
+	for _, node := range nodeMap {
+		go node.handleIncomingMessage()
+	}
+
+	return managers, nodeMap
+}
+
+/*
+Function to Run Baseline Benchmark (2 reads, 2 writes), followed by a scan when the Nodes prefetch
+*/
+func baselineBenchmark(nodeMap map[int]*Node) {
+	totalNodes := len(nodeMap)
+	for i := 1; i <= totalNodes; i++ {
+		nodeMap[i].ReadAsync(i).Wait()
+	}
+	for i := 1; i <= totalNodes; i++ {
+		toWrite := fmt.Sprintf("This is written by node id %d", i)
+		nodeMap[i].WriteAsync(i, toWrite).Wait()
+	}
+	for i := 1; i <= totalNodes; i++ {
+		temp := i + 1
+		temp %= (TOTAL_DOCS + 1)
+		if temp == 0 {
+			temp += 1
+		}
+		nodeMap[i].ReadAsync(temp).Wait()
+	}
+	for i := 1; i <= totalNodes; i++ {
+		toWrite := fmt.Sprintf("This is written by pid %d", i)
+		temp := i + 1
+		temp %= (TOTAL_DOCS + 1)
+		if temp == 0 {
+			temp += 1
+		}
+		nodeMap[i].WriteAsync(temp, toWrite).Wait()
+		//nodeMap[i].executeRead((temp+1)%TOTAL_DOCS)
+	}
+	if nodeMap[1].prefetch > 0 {
+		// Every Page has its own Owner so far, nothing could be prefetched
+		for page := 1; page <= TOTAL_DOCS; page++ {
+			nodeMap[1].WriteAsync(page, fmt.Sprintf("Page %d written by node id 1", page)).Wait()
+		}
+		scanBenchmark(nodeMap)
+	}
+}
+
+/*
+Function to Run every Node but Node 1 reading all Pages in order at the same time
+*/
+func scanBenchmark(nodeMap map[int]*Node) {
+	var scanners sync.WaitGroup
+	for id := 2; id <= len(nodeMap); id++ {
+		scanners.Add(1)
+		go func(node *Node) {
+			defer scanners.Done()
+			for page := 1; page <= TOTAL_DOCS; page++ {
+				node.ReadAsync(page).Wait()
+			}
+		}(nodeMap[id])
+	}
+	scanners.Wait()
+}
+
+/*
+Function to Kill a Node and check that every Page it owned is still readable from its Backup Holders
+*/
+func nodeFailureBenchmark(managers map[int]*CentralManager, nodeMap map[int]*Node, deadId int) {
+	fmt.Printf("**************************************************\n KILLING NODE %d  \n**************************************************\n", deadId)
+	nodeMap[deadId].killChan <- 1
+	for _, cm := range managers {
+		failure := NodeFailure{id: deadId, done: make(chan int)}
+		cm.nodeFailChan <- failure
+		<-failure.done
+	}
+	delete(nodeMap, deadId)
+
+	for i := 1; i <= TOTAL_NODES; i++ {
+		if _, alive := nodeMap[i]; !alive {
+			continue
+		}
+		for page := 1; page <= TOTAL_DOCS; page++ {
+			nodeMap[i].ReadAsync(page).Wait()
+		}
+	}
+}
+
+/*
+Function to Run a Workload where up to 5 Nodes keep writing and reading the same Page at the same time,
+so Requests reach the Nodes while the Page is moving between Owners
+*/
+func concurrentWriterBenchmark(nodeMap map[int]*Node, rounds int) {
+	writers := 5
+	if len(nodeMap) < writers {
+		writers = len(nodeMap)
+	}
+	var wg sync.WaitGroup
+	for i := 1; i <= writers; i++ {
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+			for round := 1; round <= rounds; round++ {
+				node.WriteAsync(1, fmt.Sprintf("Round %d written by node id %d", round, node.id)).Wait()
+				node.ReadAsync(1).Wait()
+				time.Sleep(time.Millisecond * time.Duration(rand.Intn(50)))
+			}
+		}(nodeMap[i])
+	}
+	wg.Wait()
+}
+
+/*
+Function to Run the Baseline Benchmark and the Concurrent Writer Benchmark on fresh Clusters and compare the Messages sent
+by the Centralized and Broadcast Managers
+*/
+func messageCountBenchmark() {
+	nodeCounts := []int{3, 7, 20}
+	modes := []ManagerMode{CENTRALIZED, BROADCAST}
+	counts := make(map[ManagerMode][]int64)
+	concurrentCounts := make(map[ManagerMode][]int64)
+
+	for _, mode := range modes {
+		for _, totalNodes := range nodeCounts {
+			managers, nodeMap := newCluster(mode, totalNodes)
+			atomic.StoreInt64(&messagesSent, 0)
+			baselineBenchmark(nodeMap)
+			counts[mode] = append(counts[mode], atomic.LoadInt64(&messagesSent))
+			shutdownCluster(managers, nodeMap)
+
+			managers, nodeMap = newCluster(mode, totalNodes)
+			atomic.StoreInt64(&messagesSent, 0)
+			concurrentWriterBenchmark(nodeMap, 3)
+			concurrentCounts[mode] = append(concurrentCounts[mode], atomic.LoadInt64(&messagesSent))
+			shutdownCluster(managers, nodeMap)
+		}
+	}
+
+	fmt.Printf("**************************************************\n MESSAGE COUNT COMPARISON  \n**************************************************\n")
+	for _, mode := range modes {
+		for i, totalNodes := range nodeCounts {
+			fmt.Printf("> %s :: Nodes: %d , Messages: %d , Concurrent Writers Messages: %d\n", mode, totalNodes, counts[mode][i], concurrentCounts[mode][i])
+		}
+	}
+}
+
+/*
+Function to add a CM Shard and then remove one while the Benchmark is running
+*/
+func shardRebalanceBenchmark(managers map[int]*CentralManager, done chan int) {
+	ring := managers[1].ring
+	newShard := NewCM(SHARD_COUNT + 1)
+	newShard.nodes = managers[1].nodes
+
+	time.Sleep(500 * time.Millisecond)
+	ring.addShard(newShard)
+	time.Sleep(500 * time.Millisecond)
+	ring.removeShard(1)
+	done <- 1
+}
+
+/*
+Function to Run a read-mostly Workload where one Node keeps writing a Page that every other Node polls
+*/
+func pollingBenchmark(nodeMap map[int]*Node, page int, rounds int) {
+	for round := 1; round <= rounds; round++ {
+		nodeMap[1].WriteAsync(page, fmt.Sprintf("Round %d written by node id 1", round)).Wait()
+		for i := 2; i <= len(nodeMap); i++ {
+			nodeMap[i].ReadAsync(page).Wait()
+		}
+	}
+}
+
+/*
+Function to compare the Messages and Time of Write Invalidate and Write Update on the Polling Workload
+*/
+func coherencePolicyBenchmark() {
+	policies := []CoherencePolicy{WRITE_INVALIDATE, WRITE_UPDATE}
+	counts := []int64{}
+	durations := []time.Duration{}
+
+	for _, policy := range policies {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		managers[0].policy = policy
+		atomic.StoreInt64(&messagesSent, 0)
+		start := time.Now()
+		pollingBenchmark(nodeMap, 1, 5)
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		shutdownCluster(managers, nodeMap)
+	}
+
+	fmt.Printf("**************************************************\n COHERENCE POLICY COMPARISON  \n**************************************************\n")
+	for i, policy := range policies {
+		fmt.Printf("> %s :: Nodes: %d , Messages: %d , Time taken = %.2f seconds\n", policy, TOTAL_NODES, counts[i], durations[i].Seconds())
+	}
+}
+
+/*
+Function to Run a batch Workload where every Node updates its neighbour's Page several times inside one Acquire/Release interval
+while another Node keeps reading that Page
+*/
+func batchBenchmark(nodeMap map[int]*Node, writes int) {
+	for i := 1; i <= len(nodeMap); i++ {
+		page := i%len(nodeMap) + 1
+		reader := (i+1)%len(nodeMap) + 1
+		nodeMap[i].Run(nodeMap[i].Acquire)
+		for w := 1; w <= writes; w++ {
+			nodeMap[i].WriteAsync(page, fmt.Sprintf("Batch %d written by node id %d", w, i)).Wait()
+			nodeMap[reader].ReadAsync(page).Wait()
+		}
+		nodeMap[i].Run(nodeMap[i].Release)
+	}
+}
+
+/*
+Function to compare the Messages and Time of Sequential and Release Consistency on the Batch Workload
+*/
+func consistencyModelBenchmark() {
+	models := []ConsistencyModel{SEQUENTIAL, RELEASE}
+	counts := []int64{}
+	durations := []time.Duration{}
+
+	for _, model := range models {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		for _, node := range nodeMap {
+			node.consistency = model
+		}
+		for i := 1; i <= TOTAL_NODES; i++ {
+			nodeMap[i].WriteAsync(i, fmt.Sprintf("This is written by node id %d", i)).Wait()
+		}
+
+		atomic.StoreInt64(&messagesSent, 0)
+		start := time.Now()
+		batchBenchmark(nodeMap, 5)
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		shutdownCluster(managers, nodeMap)
+	}
+
+	fmt.Printf("**************************************************\n CONSISTENCY MODEL COMPARISON  \n**************************************************\n")
+	for i, model := range models {
+		fmt.Printf("> %s :: Nodes: %d , Messages: %d , Time taken = %.2f seconds\n", model, TOTAL_NODES, counts[i], durations[i].Seconds())
+	}
+}
+
+/*
+Function to Run a false sharing Workload where in every round Node 1 and Node 2 write different bytes of the same Page
+in an Acquire/Release interval at the same time, and Node 3 then reads the Page
+*/
+func falseSharingBenchmark(nodeMap map[int]*Node, page int, rounds int) {
+	nodeMap[3].WriteAsync(page, strings.Repeat(" ", 40)).Wait()
+	for round := 1; round <= rounds; round++ {
+		handles := []*OpHandle{}
+		for id, offset := range map[int]int{1: 0, 2: 20} {
+			node, data := nodeMap[id], fmt.Sprintf("[node %d round %d]", id, round)
+			handles = append(handles, node.Submit(func() string {
+				node.Acquire()
+				node.writeBytes(page, offset, data)
+				node.Release()
+				return ""
+			}))
+		}
+		for _, handle := range handles {
+			handle.Wait()
+		}
+		nodeMap[3].ReadAsync(page).Wait()
+	}
+}
+
+/*
+Function to compare the Messages and Time of every Consistency Model on the False Sharing Workload
+*/
+func falseSharingComparisonBenchmark() {
+	models := []ConsistencyModel{SEQUENTIAL, RELEASE, MULTIPLE_WRITER}
+	counts := []int64{}
+	durations := []time.Duration{}
+	contents := []string{}
+	owners := []int{}
+	transfers := []int{}
+
+	for _, model := range models {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		for _, node := range nodeMap {
+			node.consistency = model
+		}
+
+		atomic.StoreInt64(&messagesSent, 0)
+		start := time.Now()
+		falseSharingBenchmark(nodeMap, 1, 5)
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		owners = append(owners, managers[0].pgOwner[1])
+		transfers = append(transfers, managers[0].pgTransfers[1])
+
+		contents = append(contents, nodeMap[3].ReadAsync(1).Wait())
+		shutdownCluster(managers, nodeMap)
+	}
+
+	fmt.Printf("**************************************************\n FALSE SHARING COMPARISON  \n**************************************************\n")
+	for i, model := range models {
+		fmt.Printf("> %s :: Messages: %d , Ownership transfers: %d , Owner: Node %d , Time taken = %.2f seconds , Final Content: %s\n", model, counts[i], transfers[i], owners[i], durations[i].Seconds(), contents[i])
+	}
+}
+
+/*
+Function to Run a shared counter Workload, Node 1 first holds the Lock for longer than a Lease and keeps it by renewing,
+then the last Node takes the Lock and dies, the others wait for its Lease to run out and then increment the counter Page
+in turns inside Lock and Unlock
+*/
+func lockBenchmark(nodeMap map[int]*Node, page int, rounds int) {
+	totalNodes := len(nodeMap)
+	nodeMap[1].Run(func() { nodeMap[1].Lock("counter") })
+	time.Sleep(LOCK_LEASE + LOCK_LEASE/2)
+	taken := false
+	nodeMap[2].Run(func() {
+		if taken = nodeMap[2].TryLock("counter"); taken {
+			nodeMap[2].Unlock("counter")
+		}
+	})
+	fmt.Printf("> [Node 1] Still holding Lock counter after %s :: Node 2 took it: %t\n", LOCK_LEASE+LOCK_LEASE/2, taken)
+	nodeMap[1].Run(func() { nodeMap[1].Unlock("counter") })
+
+	nodeMap[totalNodes].Run(func() { nodeMap[totalNodes].Lock("counter") })
+	fmt.Printf("> [Node %d] Dying while holding Lock counter\n", totalNodes)
+	nodeMap[totalNodes].killChan <- 1
+	nodeMap[1].Run(func() {
+		if nodeMap[1].TryLock("counter") {
+			nodeMap[1].Unlock("counter")
+		}
+	})
+
+	counterBenchmark(nodeMap, totalNodes-1, page, rounds)
+}
+
+/*
+Function to Run the shared counter Workload on the first Nodes, each increments the counter Page and records itself
+on the Page after it inside Lock and Unlock
+*/
+func counterBenchmark(nodeMap map[int]*Node, workerCount int, page int, rounds int) {
+	var workers sync.WaitGroup
+	for i := 1; i <= workerCount; i++ {
+		workers.Add(1)
+		go func(node *Node) {
+			defer workers.Done()
+			for round := 0; round < rounds; round++ {
+				node.Run(func() {
+					node.Lock("counter")
+					node.executeRead(page)
+					count, _ := strconv.Atoi(node.pgContent[page])
+					node.executeWrite(page, strconv.Itoa(count+1))
+					node.executeWrite(page+1, fmt.Sprintf("Last incremented by Node %d", node.id))
+					node.Unlock("counter")
+				})
+			}
+		}(nodeMap[i])
+	}
+	workers.Wait()
+}
+
+/*
+Function to compare the Messages and Time of sequential and entry consistency on the shared counter Workload
+*/
+func entryConsistencyBenchmark() {
+	models := []ConsistencyModel{SEQUENTIAL, ENTRY}
+	counts := []int64{}
+	durations := []time.Duration{}
+	contents := []string{}
+	rounds := 3
+
+	for _, model := range models {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		for _, node := range nodeMap {
+			node.consistency = model
+		}
+		if model == ENTRY {
+			nodeMap[1].Run(func() { nodeMap[1].BindPages("counter", []int{1, 2}) })
+		}
+
+		atomic.StoreInt64(&messagesSent, 0)
+		start := time.Now()
+		counterBenchmark(nodeMap, TOTAL_NODES, 1, rounds)
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+
+		nodeMap[1].Run(func() {
+			node := nodeMap[1]
+			node.Lock("counter")
+			node.executeRead(1)
+			contents = append(contents, node.pgContent[1])
+			node.Unlock("counter")
+		})
+		shutdownCluster(managers, nodeMap)
+	}
+
+	fmt.Printf("**************************************************\n ENTRY CONSISTENCY COMPARISON  \n**************************************************\n")
+	for i, model := range models {
+		fmt.Printf("> %s :: Messages: %d , Time taken = %.2f seconds , Counter: %s , Expected: %d\n", model, counts[i], durations[i].Seconds(), contents[i], TOTAL_NODES*rounds)
+	}
+}
+
+/*
+Function to Run the Lock Workload on a fresh Cluster and check that no increment was lost
+*/
+func lockComparisonBenchmark() {
+	managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+	rounds := 3
+
+	atomic.StoreInt64(&messagesSent, 0)
+	start := time.Now()
+	lockBenchmark(nodeMap, 1, rounds)
+	duration := time.Since(start)
+
+	counter := nodeMap[1].ReadAsync(1).Wait()
+	shutdownCluster(managers, nodeMap)
+	fmt.Printf("**************************************************\n LOCK BENCHMARK  \n**************************************************\n")
+	fmt.Printf("> Counter: %s , Expected: %d :: Messages: %d , Time taken = %.2f seconds\n", counter, (TOTAL_NODES-1)*rounds, atomic.LoadInt64(&messagesSent), duration.Seconds())
+}
+
+/*
+Function to Run a phased Workload, in every phase each Node writes its own Page and after a Barrier reads its neighbour's Page,
+returning how many of those reads did not see the neighbour's write of that phase
+*/
+func barrierBenchmark(nodeMap map[int]*Node, phases int) int {
+	totalNodes := len(nodeMap)
+	staleReads := int64(0)
+	var workers sync.WaitGroup
+	for i := 1; i <= totalNodes; i++ {
+		workers.Add(1)
+		go func(node *Node) {
+			defer workers.Done()
+			neighbour := node.id%totalNodes + 1
+			for phase := 1; phase <= phases; phase++ {
+				node.Run(func() {
+					node.executeWrite(node.id, fmt.Sprintf("Phase %d by Node %d", phase, node.id))
+					node.Barrier("phase", totalNodes)
+					node.executeRead(neighbour)
+					if node.pgContent[neighbour] != fmt.Sprintf("Phase %d by Node %d", phase, neighbour) {
+						atomic.AddInt64(&staleReads, 1)
+					}
+					node.Barrier("phase", totalNodes)
+				})
+			}
+		}(nodeMap[i])
+	}
+	workers.Wait()
+	return int(staleReads)
+}
+
+/*
+Function to Run a producer/consumer Workload on a Cond, Node 1 waits until Node 2 has put an item on the queue Page
+*/
+func condBenchmark(nodeMap map[int]*Node, page int) string {
+	consumed := make(chan string)
+	go func() {
+		consumer := nodeMap[1]
+		item := ""
+		consumer.Run(func() {
+			consumer.Lock("queue")
+			consumer.executeRead(page)
+			for consumer.pgContent[page] == "" {
+				consumer.Wait("nonempty", "queue")
+				consumer.executeRead(page)
+			}
+			item = consumer.pgContent[page]
+			consumer.Unlock("queue")
+		})
+		consumed <- item
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+	producer := nodeMap[2]
+	producer.Run(func() {
+		producer.Lock("queue")
+		producer.executeWrite(page, "item produced by Node 2")
+		producer.Signal("nonempty", "queue")
+		producer.Unlock("queue")
+	})
+	return <-consumed
+}
+
+/*
+Function to Run the Barrier and Cond Workloads on a fresh Cluster
+*/
+func synchronizationBenchmark() {
+	managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+	phases := 3
+
+	atomic.StoreInt64(&messagesSent, 0)
+	start := time.Now()
+	staleReads := barrierBenchmark(nodeMap, phases)
+	item := condBenchmark(nodeMap, TOTAL_NODES+1)
+	duration := time.Since(start)
+	shutdownCluster(managers, nodeMap)
+
+	fmt.Printf("**************************************************\n BARRIER AND COND BENCHMARK  \n**************************************************\n")
+	fmt.Printf("> Barrier :: Phases: %d , Stale Reads after a Barrier: %d\n", phases, staleReads)
+	fmt.Printf("> Cond :: Consumed: %s\n", item)
+	fmt.Printf("> Messages: %d , Time taken = %.2f seconds\n", atomic.LoadInt64(&messagesSent), duration.Seconds())
+}
+
+/*
+Function to Run concurrent increments of a counter Page from every Node, either as a plain read then write or as FetchAndAdd
+*/
+func atomicCounterBenchmark(nodeMap map[int]*Node, page int, rounds int, atomically bool) {
+	var workers sync.WaitGroup
+	for i := 1; i <= len(nodeMap); i++ {
+		workers.Add(1)
+		go func(node *Node) {
+			defer workers.Done()
+			for round := 0; round < rounds; round++ {
+				if atomically {
+					node.Run(func() { node.FetchAndAdd(page, 1) })
+					continue
+				}
+				count, _ := strconv.Atoi(node.ReadAsync(page).Wait())
+				node.WriteAsync(page, strconv.Itoa(count+1)).Wait()
+			}
+		}(nodeMap[i])
+	}
+	workers.Wait()
+}
+
+/*
+Function to have every Node race to CompareAndSwap an empty Page to its own id, returns how many Nodes won
+*/
+func compareAndSwapBenchmark(nodeMap map[int]*Node, page int) int {
+	winners := int64(0)
+	var workers sync.WaitGroup
+	for i := 1; i <= len(nodeMap); i++ {
+		workers.Add(1)
+		go func(node *Node) {
+			defer workers.Done()
+			node.Run(func() {
+				if node.CompareAndSwap(page, "", fmt.Sprintf("Claimed by Node %d", node.id)) {
+					atomic.AddInt64(&winners, 1)
+				}
+			})
+		}(nodeMap[i])
+	}
+	workers.Wait()
+	return int(winners)
+}
+
+/*
+Function to compare lost updates of plain and atomic increments under contention and check that exactly one CompareAndSwap wins
+*/
+func atomicOperationsBenchmark() {
+	rounds := 5
+	names := []string{"READ THEN WRITE", "FETCH AND ADD"}
+	contents := []string{}
+	for i := range names {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		atomicCounterBenchmark(nodeMap, 1, rounds, i == 1)
+		contents = append(contents, nodeMap[1].ReadAsync(1).Wait())
+		shutdownCluster(managers, nodeMap)
+	}
+
+	managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+	winners := compareAndSwapBenchmark(nodeMap, 2)
+	claimed := nodeMap[1].ReadAsync(2).Wait()
+	shutdownCluster(managers, nodeMap)
+
+	fmt.Printf("**************************************************\n ATOMIC OPERATIONS BENCHMARK  \n**************************************************\n")
+	for i, name := range names {
+		fmt.Printf("> %s :: Counter: %s , Expected: %d\n", name, contents[i], TOTAL_NODES*rounds)
+	}
+	fmt.Printf("> COMPARE AND SWAP :: Winners: %d , Page: %s\n", winners, claimed)
+
+	// The plain read then write may lose increments, the atomic operations must not
+	if contents[1] != strconv.Itoa(TOTAL_NODES*rounds) || winners != 1 {
+		fmt.Printf("> FAILED :: FETCH AND ADD Counter: %s , Expected: %d , COMPARE AND SWAP Winners: %d , Expected: 1\n", contents[1], TOTAL_NODES*rounds, winners)
+		os.Exit(1)
+	}
+}
+
+/*
+Function to Run interleaved "append" Operations on a shared log Page from every Node
+*/
+func functionShippingBenchmark(nodeMap map[int]*Node, page int, rounds int) {
+	var workers sync.WaitGroup
+	for i := 1; i <= len(nodeMap); i++ {
+		workers.Add(1)
+		go func(node *Node) {
+			defer workers.Done()
+			for round := 0; round < rounds; round++ {
+				node.Run(func() { node.Apply(page, "append", fmt.Sprintf("[Node %d]", node.id)) })
+				time.Sleep(time.Millisecond * time.Duration(rand.Intn(50)))
+			}
+		}(nodeMap[i])
+	}
+	workers.Wait()
+}
+
+/*
+Function to compare the Messages, Content moved and Time of always migrating the log Page against shipping Operations when it is contended
+*/
+func functionShippingComparisonBenchmark() {
+	rounds := 5
+	names := []string{"MIGRATION", "FUNCTION SHIPPING"}
+	counts := []int64{}
+	bytes := []int64{}
+	durations := []time.Duration{}
+	contents := []string{}
+
+	for i := range names {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		managers[0].shipping = i == 1
+
+		atomic.StoreInt64(&messagesSent, 0)
+		atomic.StoreInt64(&contentBytesSent, 0)
+		start := time.Now()
+		functionShippingBenchmark(nodeMap, 1, rounds)
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		bytes = append(bytes, atomic.LoadInt64(&contentBytesSent))
+
+		contents = append(contents, nodeMap[1].ReadAsync(1).Wait())
+		shutdownCluster(managers, nodeMap)
+	}
+
+	fmt.Printf("**************************************************\n FUNCTION SHIPPING COMPARISON  \n**************************************************\n")
+	for i, name := range names {
+		fmt.Printf("> %s :: Messages: %d , Content Bytes: %d , Time taken = %.2f seconds , Entries: %d , Expected: %d\n", name, counts[i], bytes[i], durations[i].Seconds(), strings.Count(contents[i], "["), TOTAL_NODES*rounds)
+	}
+}
+
+/*
+Function to Run concurrent transfers between two account Pages holding 100 in total, the first Nodes transfer and
+the rest check the total, returns how many checks saw a total other than 100
+*/
+func transactionBenchmark(nodeMap map[int]*Node, rounds int, transactional bool) int {
+	totalNodes := len(nodeMap)
+	writers := totalNodes / 2
+	violations := int64(0)
+
+	nodeMap[1].Run(func() {
+		setup := nodeMap[1].Begin([]int{1, 2})
+		setup.Write(1, "50")
+		setup.Write(2, "50")
+		setup.Commit()
+	})
+
+	var workers sync.WaitGroup
+	for i := 1; i <= totalNodes; i++ {
+		workers.Add(1)
+		go func(node *Node, writer bool) {
+			defer workers.Done()
+			for round := 0; round < rounds; round++ {
+				var from, to int
+				if transactional {
+					node.Run(func() {
+						tx := node.Begin([]int{1, 2})
+						from, _ = strconv.Atoi(tx.Read(1))
+						to, _ = strconv.Atoi(tx.Read(2))
+						if writer {
+							amount := rand.Intn(10) + 1
+							tx.Write(1, strconv.Itoa(from-amount))
+							tx.Write(2, strconv.Itoa(to+amount))
+						}
+						tx.Commit()
+					})
+				} else {
+					from, _ = strconv.Atoi(node.ReadAsync(1).Wait())
+					to, _ = strconv.Atoi(node.ReadAsync(2).Wait())
+					if writer {
+						amount := rand.Intn(10) + 1
+						node.WriteAsync(1, strconv.Itoa(from-amount)).Wait()
+						node.WriteAsync(2, strconv.Itoa(to+amount)).Wait()
+					}
+				}
+				if !writer && from+to != 100 {
+					atomic.AddInt64(&violations, 1)
+				}
+				time.Sleep(time.Millisecond * time.Duration(rand.Intn(100)))
+			}
+		}(nodeMap[i], i <= writers)
+	}
+	workers.Wait()
+	return int(violations)
+}
+
+/*
+Function to compare how often plain reads and writes and Transactions let the account total be seen as other than 100
+*/
+func transactionComparisonBenchmark() {
+	rounds := 5
+	names := []string{"PLAIN", "TRANSACTION"}
+	violations := []int{}
+	totals := []int{}
+	for i := range names {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		violations = append(violations, transactionBenchmark(nodeMap, rounds, i == 1))
+
+		var from, to int
+		nodeMap[1].Run(func() {
+			tx := nodeMap[1].Begin([]int{1, 2})
+			from, _ = strconv.Atoi(tx.Read(1))
+			to, _ = strconv.Atoi(tx.Read(2))
+			tx.Commit()
+		})
+		totals = append(totals, from+to)
+		shutdownCluster(managers, nodeMap)
+	}
+
+	fmt.Printf("**************************************************\n TRANSACTION BENCHMARK  \n**************************************************\n")
+	for i, name := range names {
+		fmt.Printf("> %s :: Inconsistent Totals seen: %d , Final Total: %d , Expected: 100\n", name, violations[i], totals[i])
+	}
 }
 
 /*
-Function to handle Write Forward Msgs at Node
+Function to Run a write to a Page whose only reader stopped responding, returns how long the write took
+or false if it was still blocked after the timeout
 */
-func (node *Node) handleWriteFwd(msg Message) {
-	page := msg.page
-	requesterId := msg.requesterId
+func leaseBenchmark(readLease time.Duration, timeout time.Duration) (time.Duration, bool) {
+	managers, nodeMap := newCluster(CENTRALIZED, 3)
+	defer shutdownCluster(managers, nodeMap)
+	managers[0].readLease = readLease
+	for _, node := range nodeMap {
+		node.readLease = readLease
+	}
 
-	responseMsg := createMessage(WRITEPG, node.id, requesterId, page, node.pgContent[page])
-	delete(node.pgAccess, page)
-	//delete(node.pgContent, page)
-	go node.sendMessage(*responseMsg, requesterId)
+	nodeMap[1].WriteAsync(1, "This is written by node id 1").Wait()
+	nodeMap[2].ReadAsync(1).Wait()
+	fmt.Printf("**************************************************\n NODE 2 STOPS RESPONDING  \n**************************************************\n")
+	nodeMap[2].killChan <- 1
+
+	done := make(chan time.Duration)
+	go func() {
+		start := time.Now()
+		nodeMap[3].WriteAsync(1, "This is written by node id 3").Wait()
+		done <- time.Since(start)
+	}()
+	select {
+	case duration := <-done:
+		return duration, true
+	case <-time.After(timeout):
+		return timeout, false
+	}
 }
 
 /*
-Function to handle Invalidate Msgs at Node
+Function to compare a write to a Page with an unresponsive reader with and without read leases
 */
-func (node *Node) handleInvalidate(msg Message) {
-	page := msg.page
-	delete(node.pgAccess, page)
-	//delete(node.pgContent, page)
+func leaseComparisonBenchmark() {
+	lease := 500 * time.Millisecond
+	timeout := 3 * time.Second
+	withoutDuration, withoutDone := leaseBenchmark(0, timeout)
+	withDuration, withDone := leaseBenchmark(lease, timeout)
 
-	responseMsg := createMessage(INVALIDATEACK, node.id, msg.requesterId, page, "")
-	go node.sendMessage(*responseMsg, 0)
+	fmt.Printf("**************************************************\n READ LEASE BENCHMARK  \n**************************************************\n")
+	if withoutDone {
+		fmt.Printf("> NO LEASE :: Write finished in %.2f seconds\n", withoutDuration.Seconds())
+	} else {
+		fmt.Printf("> NO LEASE :: Write still blocked on the INVALIDATEACK after %.2f seconds\n", withoutDuration.Seconds())
+	}
+	if withDone {
+		fmt.Printf("> LEASE OF %.2f SECONDS :: Write finished in %.2f seconds\n", lease.Seconds(), withDuration.Seconds())
+	} else {
+		fmt.Printf("> LEASE OF %.2f SECONDS :: Write still blocked after %.2f seconds\n", lease.Seconds(), withDuration.Seconds())
+	}
 }
 
 /*
-Function to handle Read Owner Nil Msgs at Node
+Function to Run two Nodes alternating writes to the same Page, returns how often the Page changed Owner
+and how many writes the CM held back
 */
-func (node *Node) handleReadOwnerNil(msg Message) {
-	page := msg.page
-	fmt.Printf("> [Node %d] Recieved Message of type %s for Page %d\n", node.id, msg.msgType, page)
-	responseMsg := createMessage(READACK, node.id, msg.requesterId, page, "")
-	go node.sendMessage(*responseMsg, 0)
+func thrashBenchmark(window time.Duration, rounds int) (int, int) {
+	managers, nodeMap := newCluster(CENTRALIZED, 2)
+	defer shutdownCluster(managers, nodeMap)
+	managers[0].ownershipWindow = window
+
+	var writers sync.WaitGroup
+	for i := 1; i <= 2; i++ {
+		writers.Add(1)
+		go func(node *Node) {
+			defer writers.Done()
+			for round := 0; round < rounds; round++ {
+				node.WriteAsync(1, fmt.Sprintf("Round %d written by node id %d", round, node.id)).Wait()
+				time.Sleep(time.Millisecond * time.Duration(50+rand.Intn(50)))
+			}
+		}(nodeMap[i])
+	}
+	writers.Wait()
+	managers[0].PrintState()
+	return managers[0].pgTransfers[1], managers[0].pgDeferrals[1]
 }
 
 /*
-Function to handle Write Owner Nil Msgs at Node
+Function to compare Ownership Transfers of a Page written by two Nodes in turn with and without the ownership window
 */
-func (node *Node) handleWriteOwnerNil(msg Message) {
-	page := msg.page
-	fmt.Printf("> [Node %d] Recieved Message of type %s for Page %d\n", node.id, msg.msgType, page)
+func thrashComparisonBenchmark() {
+	rounds := 10
+	windows := []time.Duration{0, 300 * time.Millisecond}
+	transfers := []int{}
+	deferrals := []int{}
+	counts := []int64{}
+	durations := []time.Duration{}
+	for _, window := range windows {
+		atomic.StoreInt64(&messagesSent, 0)
+		start := time.Now()
+		transferCount, deferralCount := thrashBenchmark(window, rounds)
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		transfers = append(transfers, transferCount)
+		deferrals = append(deferrals, deferralCount)
+	}
 
-	node.pgContent[page] = node.writeToPg
-	node.pgAccess[page] = READWRITE
+	fmt.Printf("**************************************************\n ANTI-THRASHING BENCHMARK  \n**************************************************\n")
+	for i, window := range windows {
+		fmt.Printf("> WINDOW OF %.2f SECONDS :: Transfers: %d , Deferred Writes: %d , Messages: %d , Time taken = %.2f seconds\n", window.Seconds(), transfers[i], deferrals[i], counts[i], durations[i].Seconds())
+	}
+}
 
-	responseMsg := createMessage(WRITEACK, node.id, msg.requesterId, page, "")
-	fmt.Printf("> [Node %d] Writing to Page %d\n Content:%s\n", node.id, page, node.writeToPg)
-	go node.sendMessage(*responseMsg, 0)
+/*
+Function to Run every Node reading a Page and then writing it, one Node after the other
+*/
+func upgradeBenchmark(nodeMap map[int]*Node, content string) {
+	for i := 1; i <= len(nodeMap); i++ {
+		nodeMap[i].ReadAsync(1).Wait()
+	}
+	for i := 1; i <= len(nodeMap); i++ {
+		nodeMap[i].ReadAsync(1).Wait()
+		nodeMap[i].WriteAsync(1, fmt.Sprintf("%s written by node id %d", content, i)).Wait()
+	}
 }
 
 /*
-Function to handle Read Page Msgs at Node
+Function to compare Messages and Content Bytes of reads followed by writes with and without upgrades
 */
-func (node *Node) handleReadPg(msg Message) {
-	page := msg.page
-	content := msg.content
+func upgradeComparisonBenchmark() {
+	content := strings.Repeat("x", 1000)
+	names := []string{"WRITE REQUEST", "UPGRADE"}
+	counts := []int64{}
+	bytes := []int64{}
+	for i := range names {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		for _, node := range nodeMap {
+			node.upgrade = i == 1
+		}
+		nodeMap[1].WriteAsync(1, content).Wait()
 
-	node.pgAccess[page] = READONLY
-	node.pgContent[page] = content
+		atomic.StoreInt64(&messagesSent, 0)
+		atomic.StoreInt64(&contentBytesSent, 0)
+		upgradeBenchmark(nodeMap, content)
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		bytes = append(bytes, atomic.LoadInt64(&contentBytesSent))
+		shutdownCluster(managers, nodeMap)
+	}
 
-	fmt.Printf("> [Node %d] Recieved Page %d Content from Owner for Reading\n Content: %s\n", node.id, page, content)
-	responseMsg := createMessage(READACK, node.id, msg.requesterId, page, "")
-	go node.sendMessage(*responseMsg, 0)
+	fmt.Printf("**************************************************\n READ TO WRITE UPGRADE BENCHMARK  \n**************************************************\n")
+	for i, name := range names {
+		fmt.Printf("> %s :: Messages: %d , Content Bytes: %d\n", name, counts[i], bytes[i])
+	}
 }
 
 /*
-Function to handle Write Page Msgs at Node
+Function to compare the Content Bytes sent when every Node overwrites a Page in turn with and without blind writes
 */
-func (node *Node) handleWritePg(msg Message) {
-	page := msg.page
-	content := msg.content
+func blindWriteComparisonBenchmark() {
+	content := strings.Repeat("x", 1000)
+	rounds := 3
+	names := []string{"FETCH OLD CONTENT", "BLIND WRITE"}
+	counts := []int64{}
+	bytes := []int64{}
+	for i := range names {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		for _, node := range nodeMap {
+			node.blindWrites = i == 1
+		}
 
-	fmt.Printf("> [Node %d] Recieved Old Page %d Content from Owner for Writing\n Content: %s\n", node.id, page, content)
-	node.pgAccess[page] = READWRITE
-	node.pgContent[page] = node.writeToPg
-	fmt.Printf("> [Node %d] Writing to Page %d\n Content: %s\n", node.id, page, node.writeToPg)
+		atomic.StoreInt64(&messagesSent, 0)
+		atomic.StoreInt64(&contentBytesSent, 0)
+		for round := 0; round < rounds; round++ {
+			for id := 1; id <= TOTAL_NODES; id++ {
+				nodeMap[id].WriteAsync(1, fmt.Sprintf("%s written by node id %d in round %d", content, id, round)).Wait()
+			}
+		}
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		bytes = append(bytes, atomic.LoadInt64(&contentBytesSent))
+		shutdownCluster(managers, nodeMap)
+	}
 
-	responseMsg := createMessage(WRITEACK, node.id, msg.requesterId, page, "")
-	go node.sendMessage(*responseMsg, 0)
+	fmt.Printf("**************************************************\n BLIND WRITE BENCHMARK  \n**************************************************\n")
+	for i, name := range names {
+		fmt.Printf("> %s :: Messages: %d , Content Bytes: %d\n", name, counts[i], bytes[i])
+	}
 }
 
 /*
-Function to handle Incoming Msgs at Node
+Function to compare Messages of Nodes incrementing a counter Page in turn with and without migratory detection, followed by
+a phase where every Node only reads the counter
 */
-func (node *Node) handleIncomingMessage() {
-	for {
-		msg := <-node.msgReq
-		fmt.Printf("> [Node %d] Recieved Message of type %s from CM\n", node.id, msg.msgType)
-		switch msg.msgType {
-		case READFWD:
-			node.handleReadFwd(msg)
-		case WRITEFWD:
-			node.handleWriteFwd(msg)
-		case INVALIDATE:
-			node.handleInvalidate(msg)
+func migratoryComparisonBenchmark() {
+	rounds := 3
+	names := []string{"NO DETECTION", "MIGRATORY DETECTION"}
+	counts := []int64{}
+	grants := []int{}
+	readGrants := []int{}
+	finals := []string{}
+	for i := range names {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		managers[0].migratory = i == 1
+		nodeMap[1].WriteAsync(1, "0").Wait()
+
+		atomic.StoreInt64(&messagesSent, 0)
+		for round := 0; round < rounds; round++ {
+			for id := 1; id <= TOTAL_NODES; id++ {
+				node := nodeMap[id]
+				counter, _ := strconv.Atoi(node.ReadAsync(1).Wait())
+				node.WriteAsync(1, strconv.Itoa(counter+1)).Wait()
+			}
 		}
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		grants = append(grants, managers[0].pgMigratoryGrants[1])
+		finals = append(finals, nodeMap[managers[0].pgOwner[1]].ReadAsync(1).Wait())
+
+		for round := 0; round < rounds; round++ {
+			for id := 1; id <= TOTAL_NODES; id++ {
+				nodeMap[id].ReadAsync(1).Wait()
+			}
+		}
+		readGrants = append(readGrants, managers[0].pgMigratoryGrants[1]-grants[i])
+		managers[0].PrintState()
+		shutdownCluster(managers, nodeMap)
+	}
+
+	fmt.Printf("**************************************************\n MIGRATORY DETECTION BENCHMARK  \n**************************************************\n")
+	for i, name := range names {
+		fmt.Printf("> %s :: Messages: %d , Reads granted READWRITE: %d , Counter: %s , Expected: %d\n", name, counts[i], grants[i], finals[i], TOTAL_NODES*rounds)
+		fmt.Printf("> %s :: Reads granted READWRITE in the read only phase: %d of %d\n", name, readGrants[i], TOTAL_NODES*rounds)
 	}
+	fmt.Printf("> Messages saved by migratory detection: %d\n", counts[0]-counts[1])
 }
 
 /*
-Function to perform a Read End to End at Node
+Function to compare Messages and Time of sequential scans over every Page with and without prefetching
 */
-func (node *Node) executeRead(page int) {
-	node.nodeWaitGroup.Add(1)
-	if _, exists := node.pgAccess[page]; exists {
-		content := node.pgContent[page]
-		fmt.Printf("> [Node %d] Reading Cached Page %d Content: %s\n", node.id, page, content)
-		node.nodeWaitGroup.Done()
-		return
+func prefetchComparisonBenchmark() {
+	prefetch := 3
+	names := []string{"NO PREFETCH", fmt.Sprintf("PREFETCH %d PAGES", prefetch)}
+	counts := []int64{}
+	durations := []time.Duration{}
+	hitRates := [][]float64{}
+	for i := range names {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		for page := 1; page <= TOTAL_DOCS; page++ {
+			nodeMap[1].WriteAsync(page, fmt.Sprintf("Page %d written by node id 1", page)).Wait()
+		}
+		if i == 1 {
+			for _, node := range nodeMap {
+				node.prefetch = prefetch
+			}
+		}
+
+		atomic.StoreInt64(&messagesSent, 0)
+		start := time.Now()
+		scanBenchmark(nodeMap)
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+
+		rates := []float64{}
+		for id := 2; id <= TOTAL_NODES; id++ {
+			node := nodeMap[id]
+			node.Run(func() { rates = append(rates, node.prefetchHitRate()) })
+		}
+		hitRates = append(hitRates, rates)
+		shutdownCluster(managers, nodeMap)
 	}
 
-	readReqMsg := createMessage(READREQ, node.id, node.id, page, "")
-	go node.sendMessage(*readReqMsg, 0)
+	fmt.Printf("**************************************************\n PREFETCH BENCHMARK  \n**************************************************\n")
+	for i, name := range names {
+		fmt.Printf("> %s :: Messages: %d , Time taken = %.2f seconds , Hit Rate per scanning Node: %.2f\n", name, counts[i], durations[i].Seconds(), hitRates[i])
+	}
+}
 
-	msg := <-node.msgRes
-	switch msg.msgType {
-	case READOWNERNIL:
-		node.handleReadOwnerNil(msg)
-	case READPG:
-		node.handleReadPg(msg)
+/*
+Function to compare the Messages sent by or to the CM on the polling Workload under the Centralized and the Improved Centralized Manager
+*/
+func improvedManagerBenchmark() {
+	rounds := 3
+	modes := []ManagerMode{CENTRALIZED, IMPROVED_CENTRALIZED}
+	counts := []int64{}
+	managerCounts := []int64{}
+	durations := []time.Duration{}
+	for _, mode := range modes {
+		managers, nodeMap := newCluster(mode, TOTAL_NODES)
+		atomic.StoreInt64(&messagesSent, 0)
+		atomic.StoreInt64(&managerMessages, 0)
+		start := time.Now()
+		pollingBenchmark(nodeMap, 1, rounds)
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		managerCounts = append(managerCounts, atomic.LoadInt64(&managerMessages))
+		shutdownCluster(managers, nodeMap)
+	}
+
+	requests := int64(rounds * TOTAL_NODES)
+	fmt.Printf("**************************************************\n IMPROVED CENTRALIZED MANAGER BENCHMARK  \n**************************************************\n")
+	for i, mode := range modes {
+		fmt.Printf("> %s :: Messages: %d , CM Messages: %d , CM Messages per Request: %.2f , Time taken = %.2f seconds\n", mode, counts[i], managerCounts[i], float64(managerCounts[i])/float64(requests), durations[i].Seconds())
 	}
 }
 
 /*
-Function to perform a write End to End at Node
+Function to Run a fan out Workload, in every round Node 1 writes Page 1 and then every other Node reads it at the same time,
+so the Owner invalidates every Copy and every read is broadcast to every Node, returns the highest number of goroutines
+above the number before the Workload started
 */
-func (node *Node) executeWrite(page int, content string) {
-	node.nodeWaitGroup.Add(1)
-	if accessType, exists := node.pgAccess[page]; exists {
-		if accessType == READWRITE && node.pgContent[page] == content {
-			fmt.Printf("> [Node %d] Content is same as what is trying to be written for Page %d\n", node.id, page)
-			node.nodeWaitGroup.Done()
-			return
-		} else if accessType == READWRITE {
-			node.writeToPg = content
-			node.pgAccess[page] = READWRITE
-			node.pgContent[page] = node.writeToPg
-			fmt.Printf("> [Node %d] Writing to Page %d\n Content: %s\n", node.id, page, node.writeToPg)
+func outboxBenchmark(nodeMap map[int]*Node, rounds int) int {
+	before := runtime.NumGoroutine()
+	peak := before
+	stop := make(chan int)
+	sampled := make(chan int)
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if count := runtime.NumGoroutine(); count > peak {
+					peak = count
+				}
+			case <-stop:
+				sampled <- peak
+				return
+			}
+		}
+	}()
 
-			responseMsg := createMessage(WRITEACK, node.id, node.id, page, "")
-			go node.sendMessage(*responseMsg, 0)
-			return
+	for round := 0; round < rounds; round++ {
+		nodeMap[1].WriteAsync(1, fmt.Sprintf("Round %d written by node id 1", round)).Wait()
+		handles := []*OpHandle{}
+		for i := 2; i <= len(nodeMap); i++ {
+			handles = append(handles, nodeMap[i].ReadAsync(1))
+		}
+		for _, handle := range handles {
+			handle.Wait()
 		}
 	}
+	stop <- 1
+	return <-sampled - before
+}
 
-	node.writeToPg = content
-	writeReqMsg := createMessage(WRITEREQ, node.id, node.id, page, "")
-	go node.sendMessage(*writeReqMsg, 0)
+/*
+Function to compare the goroutines, Messages and Time of the fan out Workload on Broadcast Managers with a goroutine
+per Message and with Outboxes
+*/
+func outboxComparisonBenchmark() {
+	rounds := 3
+	totalNodes := 20
+	names := []string{"GOROUTINE PER MESSAGE", "ORDERED OUTBOXES"}
+	peaks := []int{}
+	counts := []int64{}
+	durations := []time.Duration{}
+	for i := range names {
+		managers, nodeMap := newCluster(BROADCAST, totalNodes)
+		for _, node := range nodeMap {
+			node.outbox = nil
+			if i == 1 {
+				node.outbox = NewOutbox()
+				openMailbox(node.msgReq)
+				openMailbox(node.msgRes)
+			}
+		}
+		atomic.StoreInt64(&messagesSent, 0)
+		start := time.Now()
+		peaks = append(peaks, outboxBenchmark(nodeMap, rounds))
+		durations = append(durations, time.Since(start))
+		counts = append(counts, atomic.LoadInt64(&messagesSent))
+		shutdownCluster(managers, nodeMap)
+	}
 
-	msg := <-node.msgRes
-	switch msg.msgType {
-	case WRITEOWNERNIL:
-		node.handleWriteOwnerNil(msg)
-	case WRITEPG:
-		node.handleWritePg(msg)
+	fmt.Printf("**************************************************\n OUTBOX BENCHMARK  \n**************************************************\n")
+	for i, name := range names {
+		fmt.Printf("> %s :: Nodes: %d , Peak extra Goroutines: %d , Messages: %d , Time taken = %.2f seconds\n", name, totalNodes, peaks[i], counts[i], durations[i].Seconds())
 	}
 }
 
-func main() {
-	var wg sync.WaitGroup
+/*
+Function to Run a randomized Workload of reads, writes, FetchAndAdds and locked writes from two goroutines per Node,
+every Operation goes through the Node's dispatcher, returns how many FetchAndAdds ran or false if it did not finish in time
+*/
+func dispatcherBenchmark(nodeMap map[int]*Node, rounds int, timeout time.Duration) (int, bool) {
+	counterPage := len(nodeMap) + 1
+	lockedPage := len(nodeMap) + 2
+	adds := int64(0)
 
-	fmt.Printf("**************************************************\n  IVY PROTOCOL (AUTOMATED NO FAULT BENCHMARK)  \n**************************************************\n")
-	fmt.Printf("The network will have %d Nodes.\n", TOTAL_NODES)
+	var workers sync.WaitGroup
+	for i := 1; i <= len(nodeMap); i++ {
+		for worker := 0; worker < 2; worker++ {
+			workers.Add(1)
+			go func(node *Node) {
+				defer workers.Done()
+				for round := 0; round < rounds; round++ {
+					page := rand.Intn(len(nodeMap)) + 1
+					switch rand.Intn(4) {
+					case 0:
+						node.Run(func() { node.executeRead(page) })
+					case 1:
+						node.Run(func() { node.executeWrite(page, fmt.Sprintf("Round %d written by node id %d", round, node.id)) })
+					case 2:
+						node.Run(func() { node.FetchAndAdd(counterPage, 1) })
+						atomic.AddInt64(&adds, 1)
+					case 3:
+						node.Run(func() {
+							node.Lock("dispatcher")
+							node.executeWrite(lockedPage, fmt.Sprintf("Round %d written under the Lock by node id %d", round, node.id))
+							node.Unlock("dispatcher")
+						})
+					}
+				}
+			}(nodeMap[i])
+		}
+	}
 
-	fmt.Printf("\n\nThe program will start soon....\nInstructions: The Program will be fully Automated, just watch the messages log to understand the flow. \n\n")
+	done := make(chan int)
+	go func() {
+		workers.Wait()
+		done <- 1
+	}()
+	select {
+	case <-done:
+		return int(adds), true
+	case <-time.After(timeout):
+		return int(adds), false
+	}
+}
 
-	cm := NewCM()
-	cm.cmWaitGroup = &wg
+/*
+Function to Run the randomized dispatcher Workload a few times and check every run finishes and no FetchAndAdd is lost
+*/
+func dispatcherComparisonBenchmark() {
+	runs := 3
+	rounds := 5
+	timeout := 60 * time.Second
+	results := []string{}
+	for run := 1; run <= runs; run++ {
+		managers, nodeMap := newCluster(CENTRALIZED, TOTAL_NODES)
+		atomic.StoreInt64(&messagesSent, 0)
+		start := time.Now()
+		adds, finished := dispatcherBenchmark(nodeMap, rounds, timeout)
+		if !finished {
+			results = append(results, fmt.Sprintf("> RUN %d :: DEADLOCK, not finished after %.2f seconds", run, timeout.Seconds()))
+			shutdownCluster(managers, nodeMap)
+			continue
+		}
+		counter := ""
+		nodeMap[1].Run(func() {
+			node := nodeMap[1]
+			node.executeRead(TOTAL_NODES + 1)
+			counter = node.pgContent[TOTAL_NODES+1]
+		})
+		results = append(results, fmt.Sprintf("> RUN %d :: Finished in %.2f seconds , Messages: %d , Counter: %s , Expected: %d", run, time.Since(start).Seconds(), atomic.LoadInt64(&messagesSent), counter, adds))
+		shutdownCluster(managers, nodeMap)
+	}
 
-	nodeMap := make(map[int]*Node)
-	for i := 1; i <= TOTAL_NODES; i++ {
-		node := NewNode(i, *cm)
-		node.nodeWaitGroup = &wg
-		nodeMap[i] = node
+	fmt.Printf("**************************************************\n DISPATCHER BENCHMARK  \n**************************************************\n")
+	for _, result := range results {
+		fmt.Println(result)
 	}
-	cm.nodes = nodeMap
+}
 
-	for _, nodei := range nodeMap {
-		for _, nodej := range nodeMap {
-			if nodei.id != nodej.id {
-				nodei.nodes[nodej.id] = nodej
+/*
+Function to watch a Cluster for stalls, when nothing was sent, delivered or finished for the timeout while work is still
+outstanding the state is dumped once, and again only after the protocol made progress
+*/
+func startWatchdog(managers map[int]*CentralManager, nodeMap map[int]*Node, timeout time.Duration, stop chan int) {
+	markProgress()
+	go func() {
+		ticker := time.NewTicker(timeout / 4)
+		defer ticker.Stop()
+		dumped := int64(0)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				last := atomic.LoadInt64(&lastProgress)
+				stalled := time.Since(time.Unix(0, last))
+				if last == dumped || stalled < timeout || !outstanding(managers, nodeMap) {
+					continue
+				}
+				dumped = last
+				dumpStall(managers, nodeMap, stalled)
 			}
 		}
-	}
+	}()
+}
 
-	go cm.handleIncomingMessages()
+/*
+Function to Check if a Cluster still has work to do, a Message In Flight, a CM handling a Request or a Node waiting
+*/
+func outstanding(managers map[int]*CentralManager, nodeMap map[int]*Node) bool {
+	inFlightMu.Lock()
+	pending := len(inFlight)
+	inFlightMu.Unlock()
+	if pending > 0 {
+		return true
+	}
+	for _, cm := range managers {
+		cm.diagMu.Lock()
+		handling := cm.handling != nil
+		cm.diagMu.Unlock()
+		if handling {
+			return true
+		}
+	}
 	for _, node := range nodeMap {
-		go node.handleIncomingMessage()
+		node.opMu.Lock()
+		running := node.current != nil
+		node.opMu.Unlock()
+		if running || atomic.LoadInt32(&node.waiting) == 1 {
+			return true
+		}
 	}
+	return false
+}
 
-	start := time.Now()
-	for i := 1; i <= TOTAL_NODES; i++ {
-		nodeMap[i].executeRead(i)
+/*
+Function to dump what a stalled Cluster is doing, the Request each CM is handling, each Node's outstanding Operation
+and Page permissions, and every Message that was sent but not yet taken by its receiver
+*/
+func dumpStall(managers map[int]*CentralManager, nodeMap map[int]*Node, stalled time.Duration) {
+	fmt.Printf("**************************************************\n STALL DETECTED: NO PROGRESS FOR %.2f SECONDS  \n**************************************************\n", stalled.Seconds())
+	managerIds := []int{}
+	for id := range managers {
+		managerIds = append(managerIds, id)
 	}
-	for i := 1; i <= TOTAL_NODES; i++ {
-		toWrite := fmt.Sprintf("This is written by node id %d", i)
-		nodeMap[i].executeWrite(i, toWrite)
+	sort.Ints(managerIds)
+	for _, id := range managerIds {
+		cm := managers[id]
+		cm.diagMu.Lock()
+		if cm.handling == nil {
+			fmt.Printf("> [%s] Idle\n", cm.name())
+		} else {
+			fmt.Printf("> [%s] Handling %s from Node %d for Page %d since %.2f seconds\n", cm.name(), cm.handling.msgType, cm.handling.requesterId, cm.handling.page, time.Since(cm.handlingSince).Seconds())
+		}
+		cm.diagMu.Unlock()
 	}
-	for i := 1; i <= TOTAL_NODES; i++ {
-		temp := i + 1
-		temp %= (TOTAL_DOCS + 1)
-		if temp == 0 {
-			temp += 1
+
+	nodeIds := []int{}
+	for id := range nodeMap {
+		nodeIds = append(nodeIds, id)
+	}
+	sort.Ints(nodeIds)
+	for _, id := range nodeIds {
+		node := nodeMap[id]
+		operation := "none"
+		node.opMu.Lock()
+		if node.current != nil {
+			operation = strconv.FormatInt(node.current.id, 10)
+		}
+		node.opMu.Unlock()
+		fmt.Printf("> [Node %d] Operation: %s , Waiting for a Reply: %t\n", node.id, operation, atomic.LoadInt32(&node.waiting) == 1)
+		if !node.stateMu.TryLock() {
+			fmt.Printf(">   Page permissions unavailable, the Node is busy\n")
+			continue
 		}
-		nodeMap[i].executeRead(temp)
+		pages := []int{}
+		for page := range node.pgAccess {
+			pages = append(pages, page)
+		}
+		sort.Ints(pages)
+		for _, page := range pages {
+			fmt.Printf(">   Page: %d :: Access Type: %s\n", page, node.pgAccess[page])
+		}
+		node.stateMu.Unlock()
 	}
-	for i := 1; i <= TOTAL_NODES; i++ {
-		toWrite := fmt.Sprintf("This is written by pid %d", i)
-		temp := i + 1
-		temp %= (TOTAL_DOCS + 1)
-		if temp == 0 {
-			temp += 1
+
+	inFlightMu.Lock()
+	seqs := []int64{}
+	for seq := range inFlight {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	for _, seq := range seqs {
+		entry := inFlight[seq]
+		fmt.Printf("> Undelivered: %s from %s to %s for Page %d , sent %.2f seconds ago\n", entry.msg.msgType, entry.from, entry.to, entry.msg.page, time.Since(entry.sentAt).Seconds())
+	}
+	if len(seqs) == 0 {
+		fmt.Printf("> Undelivered: none\n")
+	}
+	inFlightMu.Unlock()
+}
+
+/*
+Function to stall a write on a reader that stopped responding, with leases off the CM waits for its INVALIDATEACK forever
+and the watchdog explains where the Cluster is stuck
+*/
+func stallBenchmark() {
+	timeout := time.Second
+	managers, nodeMap := newCluster(CENTRALIZED, 3)
+	stop := make(chan int)
+	startWatchdog(managers, nodeMap, timeout, stop)
+
+	nodeMap[1].WriteAsync(1, "This is written by node id 1").Wait()
+	nodeMap[2].ReadAsync(1).Wait()
+	fmt.Printf("**************************************************\n NODE 2 STOPS RESPONDING  \n**************************************************\n")
+	nodeMap[2].killChan <- 1
+	nodeMap[3].WriteAsync(1, "This is written by node id 3")
+
+	time.Sleep(3 * timeout)
+	stop <- 1
+	shutdownCluster(managers, nodeMap)
+}
+
+/*
+Function to Check the settings before the Cluster starts, features that need the CM's list of Copies cannot run on the
+improved centralized manager where the Owner keeps the Copy Set
+*/
+func checkConfig() error {
+	if MANAGER_MODE == IMPROVED_CENTRALIZED || COMPARE_IMPROVED_MANAGER {
+		needCopies := map[string]bool{
+			"COHERENCE_POLICY = WRITE_UPDATE":     COHERENCE_POLICY == WRITE_UPDATE,
+			"CONSISTENCY_MODEL = MULTIPLE_WRITER": CONSISTENCY_MODEL == MULTIPLE_WRITER,
+			"FUNCTION_SHIPPING":                   FUNCTION_SHIPPING,
+			"READ_LEASE":                          READ_LEASE > 0,
+			"READ_UPGRADE":                        READ_UPGRADE,
+			"MIGRATORY_DETECTION":                 MIGRATORY_DETECTION,
+		}
+		names := []string{}
+		for name, set := range needCopies {
+			if set {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			return fmt.Errorf("%s cannot be used with the %s manager, the CM keeps no Copy Set there", strings.Join(names, ", "), IMPROVED_CENTRALIZED)
 		}
-		nodeMap[i].executeWrite(temp, toWrite)
-		//nodeMap[i].executeRead((temp+1)%TOTAL_DOCS)
 	}
-	wg.Wait()
+	if PREFETCH_PAGES > 0 && MANAGER_MODE != CENTRALIZED {
+		return fmt.Errorf("PREFETCH_PAGES = %d only works with the %s manager, the %s manager never prefetches", PREFETCH_PAGES, CENTRALIZED, MANAGER_MODE)
+	}
+	if ORDERED_LINKS {
+		// A broadcast or a round of invalidations posts to every Node at once, and the message count and outbox
+		// benchmarks build Clusters of 20 Nodes
+		largest := TOTAL_NODES
+		if (COMPARE_MESSAGE_COUNTS || OUTBOX_BENCHMARK) && largest < 20 {
+			largest = 20
+		}
+		if OUTBOX_SIZE < largest {
+			return fmt.Errorf("OUTBOX_SIZE = %d is smaller than the %d Nodes a single Operation can send to, the sender would wait for a slot while holding its Node", OUTBOX_SIZE, largest)
+		}
+	}
+	return nil
+}
+
+func main() {
+	fmt.Printf("**************************************************\n  IVY PROTOCOL (AUTOMATED NO FAULT BENCHMARK)  \n**************************************************\n")
+	fmt.Printf("The network will have %d Nodes.\n", TOTAL_NODES)
+	fmt.Printf("The page directory is %s.\n", MANAGER_MODE)
+
+	fmt.Printf("\n\nThe program will start soon....\nInstructions: The Program will be fully Automated, just watch the messages log to understand the flow. \n\n")
+
+	if err := checkConfig(); err != nil {
+		fmt.Printf("> Invalid settings: %s\n", err)
+		os.Exit(1)
+	}
+	managers, nodeMap := newCluster(MANAGER_MODE, TOTAL_NODES)
+	if STALL_TIMEOUT > 0 {
+		startWatchdog(managers, nodeMap, STALL_TIMEOUT, make(chan int))
+	}
+
+	start := time.Now()
+	rebalanceDone := make(chan int, 1)
+	if MANAGER_MODE == SHARDED {
+		go shardRebalanceBenchmark(managers, rebalanceDone)
+	}
+	baselineBenchmark(nodeMap)
+	if MANAGER_MODE == SHARDED {
+		<-rebalanceDone
+	}
+	if REPLICATION_FACTOR > 1 && len(managers) > 0 {
+		nodeFailureBenchmark(managers, nodeMap, 1)
+	}
 	end := time.Now()
 	fmt.Printf("**************************************************\n CONCLUSION  \n**************************************************\n")
-	cm.PrintState()
+	managerIds := []int{}
+	for id := range managers {
+		managerIds = append(managerIds, id)
+	}
+	sort.Ints(managerIds)
+	for _, id := range managerIds {
+		managers[id].PrintState()
+	}
+	if MANAGER_MODE == DYNAMIC_DISTRIBUTED || MANAGER_MODE == BROADCAST || PREFETCH_PAGES > 0 {
+		for i := 1; i <= TOTAL_NODES; i++ {
+			if node, alive := nodeMap[i]; alive {
+				node.Run(node.PrintState)
+			}
+		}
+	}
 	time.Sleep(time.Second * 1)
+	fmt.Printf("Messages sent = %d \n", atomic.LoadInt64(&messagesSent))
 	fmt.Printf("Time taken = %.2f seconds \n", end.Sub(start).Seconds())
+
+	if COMPARE_MESSAGE_COUNTS {
+		messageCountBenchmark()
+	}
+	if COMPARE_COHERENCE_POLICIES {
+		coherencePolicyBenchmark()
+	}
+	if COMPARE_CONSISTENCY_MODELS {
+		consistencyModelBenchmark()
+	}
+	if COMPARE_FALSE_SHARING {
+		falseSharingComparisonBenchmark()
+	}
+	if LOCK_BENCHMARK {
+		lockComparisonBenchmark()
+	}
+	if COMPARE_ENTRY_CONSISTENCY {
+		entryConsistencyBenchmark()
+	}
+	if BARRIER_BENCHMARK {
+		synchronizationBenchmark()
+	}
+	if ATOMIC_BENCHMARK {
+		atomicOperationsBenchmark()
+	}
+	if COMPARE_FUNCTION_SHIPPING {
+		functionShippingComparisonBenchmark()
+	}
+	if TRANSACTION_BENCHMARK {
+		transactionComparisonBenchmark()
+	}
+	if LEASE_BENCHMARK {
+		leaseComparisonBenchmark()
+	}
+	if THRASH_BENCHMARK {
+		thrashComparisonBenchmark()
+	}
+	if UPGRADE_BENCHMARK {
+		upgradeComparisonBenchmark()
+	}
+	if BLIND_WRITE_BENCHMARK {
+		blindWriteComparisonBenchmark()
+	}
+	if MIGRATORY_BENCHMARK {
+		migratoryComparisonBenchmark()
+	}
+	if PREFETCH_BENCHMARK {
+		prefetchComparisonBenchmark()
+	}
+	if COMPARE_IMPROVED_MANAGER {
+		improvedManagerBenchmark()
+	}
+	if OUTBOX_BENCHMARK {
+		outboxComparisonBenchmark()
+	}
+	if DISPATCHER_BENCHMARK {
+		dispatcherComparisonBenchmark()
+	}
+	if STALL_BENCHMARK {
+		stallBenchmark()
+	}
 }